@@ -154,13 +154,12 @@ func AddBgColor(color BgColor, s string, reset bool) (string, error) {
 	return b.String(), nil
 }
 
-// ClearBgColor removes all background color formatting from a given string by replacing matching
-// patterns in BgColorLookup.
+// ClearBgColor removes all background color attributes, including 256-color and RGB ones, from the
+// SGR sequences in a given string. Sequences left empty are removed.
 func ClearBgColor(s string) string {
-	for _, color := range BgColorLookup {
-		s = strings.ReplaceAll(s, color.String(), "")
-	}
-	return s
+	return filterSGR(s, func(a sgrAttr) bool {
+		return !a.isBg()
+	})
 }
 
 // SetBgColor changes the terminal background color to the specified `BgColor`.
@@ -0,0 +1,134 @@
+package ansicolor
+
+import (
+	"strings"
+)
+
+// DiscordProfile describes what Discord displays in ```ansi code blocks: the 8 standard foreground and background
+// colors, bold and underline.
+var DiscordProfile = Profile{Colors: LevelBasic, Options: SGROptBold | SGROptUnderline}
+
+// discordFence is the fence of a Discord code block. Runs of backticks in the text are broken up with zero width
+// spaces so that they cannot end the block.
+const discordFence = "```"
+
+// zeroWidthSpace is inserted into runs of backticks to break them up without changing how the text looks.
+const zeroWidthSpace = "\u200b"
+
+// markdownSpecials are the characters escaped with a backslash by ToChatMarkdown.
+var markdownSpecials = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `~`, `\~`, `|`, `\|`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`,
+)
+
+// ToDiscord converts text containing escape sequences to a ```ansi code block for Discord chat messages. Colors are
+// mapped to the closest of the 8 standard colors, bright colors to their non-bright variants, and every option
+// other than bold and underline is dropped, as are escape sequences other than SGR. Each change of style is written
+// as a full reset followed by the new style, which is the only form Discord displays reliably.
+func ToDiscord(s string) string {
+	var b strings.Builder
+	b.WriteString(discordFence + "ansi\n")
+	cur, ticks := "", 0
+	for _, span := range Decode(s).Spans() {
+		if params := discordSGR(span.Format); params != cur {
+			b.WriteString(StartFormat + "0" + params + EndFormat)
+			cur, ticks = params, 0
+		}
+		ticks = writeDiscordText(&b, span.Text, ticks)
+	}
+	if cur != "" {
+		b.WriteString(ClearString)
+	}
+	if body := b.String(); !strings.HasSuffix(body, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(discordFence)
+	return b.String()
+}
+
+// writeDiscordText writes text to b with a zero width space after every second backtick of a run, so that the text
+// never holds a code block fence. ticks is the number of backticks ending what b holds so far; the number ending
+// the text written is returned.
+func writeDiscordText(b *strings.Builder, text string, ticks int) int {
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] != '`':
+			ticks = 0
+		case ticks == 2:
+			b.WriteString(zeroWidthSpace)
+			ticks = 1
+		default:
+			ticks++
+		}
+		b.WriteByte(text[i])
+	}
+	return ticks
+}
+
+// discordSGR returns the SGR parameters displaying a Format in Discord, each prefixed with a semicolon to follow
+// a reset. Returns an empty string for text without attributes.
+func discordSGR(f *Format) string {
+	var b strings.Builder
+	if fg := f.fgShort(DiscordProfile.Colors); fg != "" && (f.fgx != nil || !equalFg(f.fg, nil)) {
+		b.WriteString(";" + fg)
+	}
+	if bg := f.bgShort(DiscordProfile.Colors); bg != "" && (f.bgx != nil || !equalBg(f.bg, nil)) {
+		b.WriteString(";" + bg)
+	}
+	if opts := f.opts & DiscordProfile.Options; opts != 0 {
+		b.WriteString(";" + opts.String())
+	}
+	return b.String()
+}
+
+// ToChatMarkdown converts text containing escape sequences to Markdown for chat tools that display nothing but
+// bold and italic text. Bold text is written as **bold**, italic text as *italic*, and every other attribute and
+// escape sequence is dropped. Markdown punctuation in the text is escaped with backslashes.
+//
+// Emphasis never spans lines and the markers are placed around the text without its leading and trailing
+// spaces, as most chat tools require.
+func ToChatMarkdown(s string) string {
+	var b strings.Builder
+	var text strings.Builder
+	marker := ""
+	flush := func() {
+		writeEmphasis(&b, text.String(), marker)
+		text.Reset()
+	}
+	for _, span := range Decode(s).Spans() {
+		m := ""
+		if span.Format.HasOption(SGROptBold) {
+			m += "**"
+		}
+		if span.Format.HasOption(SGROptItalic) {
+			m += "*"
+		}
+		if m != marker {
+			flush()
+			marker = m
+		}
+		text.WriteString(span.Text)
+	}
+	flush()
+	return b.String()
+}
+
+// writeEmphasis writes text surrounded by an emphasis marker, line by line, keeping white space outside the
+// markers.
+func writeEmphasis(b *strings.Builder, text, marker string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		trimmed := strings.TrimSpace(line)
+		if marker == "" || trimmed == "" {
+			b.WriteString(markdownSpecials.Replace(line))
+			continue
+		}
+		start := strings.Index(line, trimmed)
+		b.WriteString(line[:start])
+		b.WriteString(marker)
+		b.WriteString(markdownSpecials.Replace(trimmed))
+		b.WriteString(marker)
+		b.WriteString(line[start+len(trimmed):])
+	}
+}
@@ -0,0 +1,62 @@
+package ansicolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToDiscord(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: "```ansi\nplain\n```"},
+		{in: "a\n", want: "```ansi\na\n```"},
+		{in: "\033[1;91mFAIL\033[0m ok", want: "```ansi\n\033[0;31;1mFAIL\033[0m ok\n```"},
+		{in: "\033[3;4;45mx\033[0m", want: "```ansi\n\033[0;45;4mx\033[0m\n```"},
+		{in: "a```b", want: "```ansi\na``\u200b`b\n```"},
+		{in: "a````b", want: "```ansi\na``\u200b``b\n```"},
+		{in: "``\033[1m`x", want: "```ansi\n``\033[0;1m`x\033[0m\n```"},
+		// Italic and strike are not displayed, so the backticks end up next to each other.
+		{in: "\033[3m`\033[9m``\033[0m", want: "```ansi\n``\u200b`\n```"},
+	}
+	for _, tt := range tests {
+		if got := ToDiscord(tt.in); got != tt.want {
+			t.Errorf("ToDiscord(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToDiscordFences(t *testing.T) {
+	for n := 1; n <= 10; n++ {
+		ticks := strings.Repeat("`", n)
+		for _, in := range []string{ticks, "x" + ticks + "x", "\033[1m" + ticks} {
+			got := ToDiscord(in)
+			body := strings.TrimSuffix(strings.TrimPrefix(got, "```ansi\n"), "\n```")
+			if strings.Contains(body, discordFence) {
+				t.Errorf("ToDiscord(%q) = %q holds a code block fence", in, got)
+			}
+			if plain := strings.ReplaceAll(Strip(body), zeroWidthSpace, ""); strings.Count(plain, "`") != n {
+				t.Errorf("ToDiscord(%q) = %q, want %d backticks", in, got, n)
+			}
+		}
+	}
+}
+
+func TestToChatMarkdown(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "\033[1mFAIL\033[0m pkg/api", want: "**FAIL** pkg/api"},
+		{in: "  \033[1;3mboth \033[0m", want: "  ***both*** "},
+		{in: "\033[3ma\nb\033[0m", want: "*a*\n*b*"},
+		{in: "a_b*c", want: `a\_b\*c`},
+		{in: "\033[31mred\033[0m", want: "red"},
+	}
+	for _, tt := range tests {
+		if got := ToChatMarkdown(tt.in); got != tt.want {
+			t.Errorf("ToChatMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
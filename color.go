@@ -0,0 +1,229 @@
+package ansicolor
+
+import (
+	"strconv"
+)
+
+// Color represents an extended terminal color: either an entry of the 256-color palette or a 24-bit RGB value.
+// Extended colors are emitted with the SGR 38 (foreground) and 48 (background) codes.
+type Color struct {
+	rgb   RGB
+	index uint8
+	isRGB bool
+}
+
+// Color256 creates a Color referring to an entry of the 256-color palette.
+func Color256(index uint8) Color {
+	return Color{index: index}
+}
+
+// TrueColor creates a 24-bit Color from the provided red, green and blue channels.
+func TrueColor(r, g, b uint8) Color {
+	return Color{rgb: RGB{R: r, G: g, B: b}, isRGB: true}
+}
+
+// IsRGB reports whether the Color is a 24-bit RGB value rather than a palette entry.
+func (c Color) IsRGB() bool {
+	return c.isRGB
+}
+
+// Index returns the 256-color palette entry of the Color, approximating 24-bit colors with the closest entry.
+func (c Color) Index() uint8 {
+	if !c.isRGB {
+		return c.index
+	}
+	return nearest256(c.rgb)
+}
+
+// RGB returns the 24-bit value of the Color. Palette entries are resolved using DefaultPalette for the first 16
+// entries and the standard xterm values for the color cube and grayscale ramp.
+func (c Color) RGB() RGB {
+	if c.isRGB {
+		return c.rgb
+	}
+	return palette256RGB(c.index, &DefaultPalette)
+}
+
+// FgColor returns the closest of the 16 standard foreground colors.
+func (c Color) FgColor() FgColor {
+	return fgFromIndex(c.nearest16())
+}
+
+// BgColor returns the closest of the 16 standard background colors.
+func (c Color) BgColor() BgColor {
+	return bgFromIndex(c.nearest16())
+}
+
+// String returns the Color as "#rrggbb" for RGB values or as the decimal palette index.
+func (c Color) String() string {
+	if c.isRGB {
+		return c.rgb.Hex()
+	}
+	return strconv.Itoa(int(c.index))
+}
+
+// fgShort returns the SGR parameters selecting the Color as foreground.
+func (c Color) fgShort() string {
+	return "38;" + c.short()
+}
+
+// bgShort returns the SGR parameters selecting the Color as background.
+func (c Color) bgShort() string {
+	return "48;" + c.short()
+}
+
+// ulShortAt returns the SGR parameters selecting the Color as underline color, approximated for the color level.
+func (c Color) ulShortAt(level ColorLevel) string {
+	switch level {
+	case LevelTrueColor:
+		return "58;" + c.short()
+	case LevelANSI256:
+		return "58;5;" + strconv.Itoa(int(c.Index()))
+	}
+	return ""
+}
+
+// fgShortAt returns the SGR parameters selecting the Color as foreground, approximated for the color level.
+func (c Color) fgShortAt(level ColorLevel) string {
+	switch level {
+	case LevelTrueColor:
+		return c.fgShort()
+	case LevelANSI256:
+		return "38;5;" + strconv.Itoa(int(c.Index()))
+	case LevelANSI:
+		return c.FgColor().Short()
+	case LevelBasic:
+		return fgFromIndex(c.nearest8()).Short()
+	}
+	return ""
+}
+
+// bgShortAt returns the SGR parameters selecting the Color as background, approximated for the color level.
+func (c Color) bgShortAt(level ColorLevel) string {
+	switch level {
+	case LevelTrueColor:
+		return c.bgShort()
+	case LevelANSI256:
+		return "48;5;" + strconv.Itoa(int(c.Index()))
+	case LevelANSI:
+		return c.BgColor().Short()
+	case LevelBasic:
+		return bgFromIndex(c.nearest8()).Short()
+	}
+	return ""
+}
+
+// short returns the SGR color specification shared by the foreground and background codes.
+func (c Color) short() string {
+	if c.isRGB {
+		return "2;" + strconv.Itoa(int(c.rgb.R)) + ";" + strconv.Itoa(int(c.rgb.G)) + ";" + strconv.Itoa(int(c.rgb.B))
+	}
+	return "5;" + strconv.Itoa(int(c.index))
+}
+
+// nearest16 returns the palette index (0-15) of the standard color closest to the Color.
+func (c Color) nearest16() int {
+	if !c.isRGB && c.index < 16 {
+		return int(c.index)
+	}
+	return nearestPaletteIndex(c.RGB(), DefaultPalette.Colors[:])
+}
+
+// nearest8 returns the palette index (0-7) of the non-bright standard color closest to the Color.
+func (c Color) nearest8() int {
+	if !c.isRGB && c.index < 16 {
+		return int(c.index % 8)
+	}
+	return nearestPaletteIndex(c.RGB(), DefaultPalette.Colors[:8])
+}
+
+// fgFromIndex returns the FgColor displayed with the palette entry at index (0-15).
+func fgFromIndex(index int) FgColor {
+	if index < 8 {
+		return FgBlack + FgColor(index)
+	}
+	return FgBrightBlack + FgColor(index-8)
+}
+
+// bgFromIndex returns the BgColor displayed with the palette entry at index (0-15).
+func bgFromIndex(index int) BgColor {
+	if index < 8 {
+		return BgBlack + BgColor(index)
+	}
+	return BgBrightBlack + BgColor(index-8)
+}
+
+// cubeLevels holds the channel intensities of the 6x6x6 color cube of the 256-color palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// palette256RGB returns the RGB value of a 256-color palette entry, taking the first 16 entries from p.
+func palette256RGB(index uint8, p *Palette) RGB {
+	switch {
+	case index < 16:
+		return p.Colors[index]
+	case index < 232:
+		i := index - 16
+		return RGB{R: cubeLevels[i/36], G: cubeLevels[(i/6)%6], B: cubeLevels[i%6]}
+	}
+	v := 8 + 10*(index-232)
+	return RGB{R: v, G: v, B: v}
+}
+
+// nearest256 returns the entry of the color cube or grayscale ramp closest to c.
+// The first 16 entries are skipped because their values depend on the user's palette.
+func nearest256(c RGB) uint8 {
+	cube := func(v uint8) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if absDiff(v, level) < absDiff(v, cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	r, g, b := cube(c.R), cube(c.G), cube(c.B)
+	cubeIndex := uint8(16 + 36*r + 6*g + b)
+	cubeRGB := RGB{R: cubeLevels[r], G: cubeLevels[g], B: cubeLevels[b]}
+
+	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
+	grayStep := 0
+	if avg > 8 {
+		grayStep = (avg - 8 + 5) / 10
+	}
+	if grayStep > 23 {
+		grayStep = 23
+	}
+	grayIndex := uint8(232 + grayStep)
+	gray := uint8(8 + 10*grayStep)
+
+	if colorDistance(c, RGB{R: gray, G: gray, B: gray}) < colorDistance(c, cubeRGB) {
+		return grayIndex
+	}
+	return cubeIndex
+}
+
+// nearestPaletteIndex returns the index of the color in candidates closest to c.
+func nearestPaletteIndex(c RGB, candidates []RGB) int {
+	best, bestDist := 0, -1
+	for i, candidate := range candidates {
+		if d := colorDistance(c, candidate); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// colorDistance returns a perceptually weighted squared distance between two colors ("redmean" approximation).
+func colorDistance(a, b RGB) int {
+	rMean := (int(a.R) + int(b.R)) / 2
+	dr, dg, db := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+	return ((512+rMean)*dr*dr)>>8 + 4*dg*dg + ((767-rMean)*db*db)>>8
+}
+
+// absDiff returns the absolute difference between two channel values.
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
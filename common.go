@@ -15,7 +15,7 @@ const ClearString = "\033[0m"
 
 // Reset sets the terminal color to the default value.
 func Reset() {
-	fmt.Print(GetDefaultFormat().String())
+	fmt.Print(GetDefaultFormat().Render(GetProfile()))
 }
 
 // ClearColor resets both the foreground and background colors of the terminal to their default values.
@@ -0,0 +1,66 @@
+package ansicolor
+
+// Decoder turns terminal output into a StyledText: text is paired with the Format in effect according to the
+// SGR sequences that precede it. Newlines and tabs are kept as text; other control characters and escape
+// sequences, such as cursor movements and hyperlinks, are dropped.
+//
+// A Decoder is an io.Writer, so it can collect the output of a child process as it is produced. Sequences split
+// across writes are decoded once they are complete. A Decoder is not safe for concurrent use.
+type Decoder struct {
+	lex    *Lexer
+	format *Format
+	text   *StyledText
+}
+
+// NewDecoder creates a Decoder starting without any attributes set.
+func NewDecoder() *Decoder {
+	d := &Decoder{
+		format: NewFormat(),
+		text:   NewStyledText(),
+	}
+	d.lex = NewLexer(d.token)
+	return d
+}
+
+// Decode decodes the escape sequences in s into a StyledText. See Decoder.
+func Decode(s string) *StyledText {
+	d := NewDecoder()
+	_, _ = d.WriteString(s)
+	_ = d.Close()
+	return d.Text()
+}
+
+// Write decodes p. It always consumes all of p and never fails.
+func (d *Decoder) Write(p []byte) (int, error) {
+	return d.lex.Write(p)
+}
+
+// WriteString decodes s. It always consumes all of s and never fails.
+func (d *Decoder) WriteString(s string) (int, error) {
+	return d.lex.WriteString(s)
+}
+
+// Close signals the end of the input. An unfinished escape sequence at the end of the input is dropped.
+func (d *Decoder) Close() error {
+	return d.lex.Close()
+}
+
+// Text returns the text decoded so far.
+func (d *Decoder) Text() *StyledText {
+	return d.text.clone()
+}
+
+// Format returns the Format in effect at the end of the text decoded so far.
+func (d *Decoder) Format() *Format {
+	return d.format
+}
+
+// token adds a token from the Lexer to the decoded text.
+func (d *Decoder) token(tok Token) {
+	switch {
+	case tok.IsSGR():
+		d.format = d.format.ApplySGR(tok.Params)
+	case keepPlain(tok):
+		d.text.appendSpan(Span{Text: tok.Raw, Format: d.format})
+	}
+}
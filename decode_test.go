@@ -0,0 +1,85 @@
+package ansicolor
+
+import (
+	"strconv"
+	"testing"
+)
+
+// equalSpans reports whether the spans of t are want, comparing Formats with Format.Equal.
+func equalSpans(t *StyledText, want []Span) bool {
+	got := t.Spans()
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].Text != want[i].Text || !got[i].Format.Equal(want[i].Format) {
+			return false
+		}
+	}
+	return true
+}
+
+// spansString formats spans for test failures, showing each Format as a style specification.
+func spansString(spans []Span) string {
+	s := ""
+	for _, span := range spans {
+		spec, _ := span.Format.MarshalText()
+		s += "{" + strconv.Quote(span.Text) + " " + string(spec) + "}"
+	}
+	return s
+}
+
+func TestDecode(t *testing.T) {
+	bold := NewFormat().WithOption(SGROptBold)
+	red := NewFormat().WithForeground(FgRed)
+	tests := []struct {
+		in   string
+		want []Span
+	}{
+		{in: "", want: nil},
+		{in: "plain", want: []Span{{Text: "plain", Format: NewFormat()}}},
+		{in: "\033[1mbold\033[0m plain", want: []Span{
+			{Text: "bold", Format: bold},
+			{Text: " plain", Format: NewFormat()},
+		}},
+		{in: "\033[1;31mx\033[22my\033[39mz", want: []Span{
+			{Text: "x", Format: bold.WithForeground(FgRed)},
+			{Text: "y", Format: red},
+			{Text: "z", Format: NewFormat()},
+		}},
+		{in: "\033[38;5;208ma\033[48;2;1;2;3mb", want: []Span{
+			{Text: "a", Format: NewFormat().WithForegroundColor(Color256(208))},
+			{Text: "b", Format: NewFormat().WithForegroundColor(Color256(208)).
+				WithBackgroundColor(TrueColor(1, 2, 3))},
+		}},
+		{in: "\033[31ma\033[mb", want: []Span{{Text: "a", Format: red}, {Text: "b", Format: NewFormat()}}},
+		// Adjacent spans with the same Format are merged.
+		{in: "\033[31ma\033[31mb", want: []Span{{Text: "ab", Format: red}}},
+		// Newlines and tabs are kept; other controls and sequences are dropped.
+		{in: "a\tb\r\n\033[2K\033]0;title\a\033]8;;http://x\033\\c\a", want: []Span{
+			{Text: "a\tb\nc", Format: NewFormat()},
+		}},
+		{in: "\u009b1mC1", want: []Span{{Text: "C1", Format: bold}}},
+		{in: "\033[1", want: nil},
+	}
+	for _, tt := range tests {
+		if got := Decode(tt.in); !equalSpans(got, tt.want) {
+			t.Errorf("Decode(%q) = %s, want %s", tt.in, spansString(got.Spans()), spansString(tt.want))
+		}
+	}
+}
+
+func TestDecoderChunks(t *testing.T) {
+	d := NewDecoder()
+	for _, chunk := range []string{"\033[", "1", "m", "a", "b\033[0", "mc"} {
+		_, _ = d.WriteString(chunk)
+	}
+	_ = d.Close()
+	want := []Span{{Text: "ab", Format: NewFormat().WithOption(SGROptBold)}, {Text: "c", Format: NewFormat()}}
+	if got := d.Text(); !equalSpans(got, want) {
+		t.Errorf("Decoder.Text() = %s, want %s", spansString(got.Spans()), spansString(want))
+	}
+	if !d.Format().IsZero() {
+		t.Errorf("Decoder.Format() = %v, want no attributes", d.Format())
+	}
+}
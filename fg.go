@@ -152,13 +152,12 @@ func AddFgColor(color FgColor, s string, reset bool) (string, error) {
 	return b.String(), nil
 }
 
-// ClearFgColor removes all ANSI escape sequences associated with FgColor from the
-// provided string and returns the result.
+// ClearFgColor removes all foreground color attributes, including 256-color and RGB ones, from the
+// SGR sequences in the provided string and returns the result. Sequences left empty are removed.
 func ClearFgColor(s string) string {
-	for _, c := range FgColorLookup {
-		s = strings.ReplaceAll(s, c.String(), "")
-	}
-	return s
+	return filterSGR(s, func(a sgrAttr) bool {
+		return !a.isFg()
+	})
 }
 
 // SetFgColor sets the foreground color for terminal text if the provided FgColor value is valid.
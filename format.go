@@ -25,7 +25,7 @@ func GetDefaultFormat() *Format {
 // DefaultFormat outputs the string representation of the default terminal text format to the standard output.
 // Shorthand for defaultFormat.Set()
 func DefaultFormat() {
-	fmt.Print(defaultFormat.String())
+	fmt.Print(defaultFormat.Render(GetProfile()))
 }
 
 // SetDefault sets the global default format to the provided Format instance.
@@ -41,6 +41,9 @@ type Format struct {
 	// avoid zero values - zero represents the ANSI reset code and removes all formatting
 	fg   *FgColor
 	bg   *BgColor
+	fgx  *Color    // Extended foreground color, mutually exclusive with fg
+	bgx  *Color    // Extended background color, mutually exclusive with bg
+	ulx  *Color    // Underline color, nil for the foreground color
 	opts SGROption // 0 values here is ok, it signifies no additional options
 	fStr string    // Cached string representation of the format
 }
@@ -60,6 +63,8 @@ func (f *Format) WithForeground(fg FgColor) *Format {
 	nf := &Format{
 		fg:   &fg,
 		bg:   f.bg,
+		bgx:  f.bgx,
+		ulx:  f.ulx,
 		opts: f.opts,
 	}
 	nf.gen()
@@ -71,13 +76,57 @@ func (f *Format) WithForeground(fg FgColor) *Format {
 func (f *Format) WithBackground(bg BgColor) *Format {
 	nf := &Format{
 		fg:   f.fg,
+		fgx:  f.fgx,
 		bg:   &bg,
+		ulx:  f.ulx,
 		opts: f.opts,
 	}
 	nf.gen()
 	return nf
 }
 
+// WithForegroundColor creates a new Format instance with the specified extended (256-color or RGB) foreground
+// color, replacing any foreground color set before while preserving other properties.
+func (f *Format) WithForegroundColor(c Color) *Format {
+	nf := &Format{
+		fgx:  &c,
+		bg:   f.bg,
+		bgx:  f.bgx,
+		ulx:  f.ulx,
+		opts: f.opts,
+	}
+	nf.gen()
+	return nf
+}
+
+// WithBackgroundColor creates a new Format instance with the specified extended (256-color or RGB) background
+// color, replacing any background color set before while preserving other properties.
+func (f *Format) WithBackgroundColor(c Color) *Format {
+	nf := &Format{
+		fg:   f.fg,
+		fgx:  f.fgx,
+		bgx:  &c,
+		ulx:  f.ulx,
+		opts: f.opts,
+	}
+	nf.gen()
+	return nf
+}
+
+// WithUnderlineColor creates a new Format instance with the specified underline color (SGR 58) while preserving
+// other properties. Terminals without support for underline colors draw underlines in the foreground color.
+func (f *Format) WithUnderlineColor(c Color) *Format {
+	return f.withUnderline(&c)
+}
+
+// withUnderline returns a copy of f with the provided underline color, nil for the default.
+func (f *Format) withUnderline(c *Color) *Format {
+	nf := f.clone()
+	nf.ulx = c
+	nf.gen()
+	return nf
+}
+
 // WithOption creates a new Format with the specified SGROption applied without modifying the original instance.
 func (f *Format) WithOption(opt SGROption) *Format {
 	opts := f.opts
@@ -85,6 +134,9 @@ func (f *Format) WithOption(opt SGROption) *Format {
 	nf := &Format{
 		fg:   f.fg,
 		bg:   f.bg,
+		fgx:  f.fgx,
+		bgx:  f.bgx,
+		ulx:  f.ulx,
 		opts: opts,
 	}
 	nf.gen()
@@ -103,33 +155,191 @@ func (f *Format) HasAnyOption(opts SGROption) bool {
 	return f.opts.HasAny(opts)
 }
 
+// Options returns the SGROptions set on the Format instance.
+func (f *Format) Options() SGROption {
+	return f.opts
+}
+
+// Foreground returns the standard foreground color of the Format instance, if one is set.
+func (f *Format) Foreground() (FgColor, bool) {
+	if f.fg == nil {
+		return -1, false
+	}
+	return *f.fg, true
+}
+
+// Background returns the standard background color of the Format instance, if one is set.
+func (f *Format) Background() (BgColor, bool) {
+	if f.bg == nil {
+		return -1, false
+	}
+	return *f.bg, true
+}
+
+// ForegroundColor returns the extended (256-color or RGB) foreground color of the Format instance, if one is set.
+func (f *Format) ForegroundColor() (Color, bool) {
+	if f.fgx == nil {
+		return Color{}, false
+	}
+	return *f.fgx, true
+}
+
+// BackgroundColor returns the extended (256-color or RGB) background color of the Format instance, if one is set.
+func (f *Format) BackgroundColor() (Color, bool) {
+	if f.bgx == nil {
+		return Color{}, false
+	}
+	return *f.bgx, true
+}
+
+// UnderlineColor returns the underline color of the Format instance, if one is set.
+func (f *Format) UnderlineColor() (Color, bool) {
+	if f.ulx == nil {
+		return Color{}, false
+	}
+	return *f.ulx, true
+}
+
+// IsZero reports whether the Format displays like the terminal default: no colors other than the default ones
+// and no options.
+func (f *Format) IsZero() bool {
+	return f.Equal(NewFormat())
+}
+
+// Equal reports whether two Format instances display the same. An explicit FgDefault or BgDefault is equal to
+// no color at all.
+func (f *Format) Equal(o *Format) bool {
+	if f == nil || o == nil {
+		return f == o
+	}
+	return f.opts == o.opts &&
+		equalFg(f.fg, o.fg) && equalBg(f.bg, o.bg) &&
+		equalColor(f.fgx, o.fgx) && equalColor(f.bgx, o.bgx) && equalColor(f.ulx, o.ulx)
+}
+
+// overlay returns a new Format with o applied on top of f: the colors set in o replace those of f and the
+// options of both are combined.
+func (f *Format) overlay(o *Format) *Format {
+	nf := f.clone()
+	if o.fg != nil || o.fgx != nil {
+		nf.fg, nf.fgx = o.fg, o.fgx
+	}
+	if o.bg != nil || o.bgx != nil {
+		nf.bg, nf.bgx = o.bg, o.bgx
+	}
+	if o.ulx != nil {
+		nf.ulx = o.ulx
+	}
+	nf.opts.Set(o.opts)
+	nf.gen()
+	return nf
+}
+
+// equalFg compares two optional foreground colors, treating FgDefault as unset.
+func equalFg(a, b *FgColor) bool {
+	if a != nil && *a == FgDefault {
+		a = nil
+	}
+	if b != nil && *b == FgDefault {
+		b = nil
+	}
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// equalBg compares two optional background colors, treating BgDefault as unset.
+func equalBg(a, b *BgColor) bool {
+	if a != nil && *a == BgDefault {
+		a = nil
+	}
+	if b != nil && *b == BgDefault {
+		b = nil
+	}
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// equalColor compares two optional extended colors.
+func equalColor(a, b *Color) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
 func (f *Format) gen() {
-	// Build the format string
-	var b strings.Builder
-	// Get started
-	b.WriteString(StartFormat)
+	// Cache the string with every color and option
+	f.fStr = f.Render(ProfileTrueColor)
+}
+
+// Render returns the ANSI escape sequence for the Format as displayed by a terminal with the provided Profile.
+// Colors beyond the profile's color level are approximated with the closest supported color and unsupported
+// options are left out. Returns an empty string if nothing remains to be emitted.
+func (f *Format) Render(p Profile) string {
+	if f.fg == nil && f.bg == nil && f.fgx == nil && f.bgx == nil && f.ulx == nil && f.opts == 0 {
+		return ""
+	}
+	codes := make([]string, 0, 3)
 	// if we have a fg color, add it
-	if f.fg != nil {
-		b.WriteString(f.fg.Short())
-		// if we have a bg color or options, add a semicolon
-		b.WriteString(";")
+	if fg := f.fgShort(p.Colors); fg != "" {
+		codes = append(codes, fg)
 	}
 	// if we have a bg color, add it
-	if f.bg != nil {
-		b.WriteString(f.bg.Short())
-		// if we have options, add a semicolon
-		b.WriteString(";")
-	}
-	// if we have options, add them
-	if f.opts != 0 {
-		b.WriteString(f.opts.String())
-	} else if f.opts == 0 {
-		b.WriteString(SGRClearStringShort)
-	}
-	// End the format string
-	b.WriteString(EndFormat)
-	// Cache the string
-	f.fStr = b.String()
+	if bg := f.bgShort(p.Colors); bg != "" {
+		codes = append(codes, bg)
+	}
+	// if we have an underline color, add it
+	if ul := f.ulShort(p.Colors); ul != "" {
+		codes = append(codes, ul)
+	}
+	// if we have options, add the supported ones, otherwise clear any options left over
+	if opts := f.opts & p.Options; opts != 0 {
+		codes = append(codes, opts.String())
+	} else if f.opts == 0 && p.Options != 0 {
+		codes = append(codes, SGRClearStringShort)
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return StartFormat + strings.Join(codes, ";") + EndFormat
+}
+
+// fgShort returns the SGR parameters for the foreground color of the Format at the provided color level.
+func (f *Format) fgShort(level ColorLevel) string {
+	switch {
+	case level == LevelNoColor:
+		return ""
+	case f.fgx != nil:
+		return f.fgx.fgShortAt(level)
+	case f.fg != nil:
+		c := *f.fg
+		if level == LevelBasic && c >= FgBrightBlack {
+			c -= FgBrightBlack - FgBlack
+		}
+		return c.Short()
+	}
+	return ""
+}
+
+// bgShort returns the SGR parameters for the background color of the Format at the provided color level.
+func (f *Format) bgShort(level ColorLevel) string {
+	switch {
+	case level == LevelNoColor:
+		return ""
+	case f.bgx != nil:
+		return f.bgx.bgShortAt(level)
+	case f.bg != nil:
+		c := *f.bg
+		if level == LevelBasic && c >= BgBrightBlack {
+			c -= BgBrightBlack - BgBlack
+		}
+		return c.Short()
+	}
+	return ""
+}
+
+// ulShort returns the SGR parameters for the underline color of the Format at the provided color level. Terminals
+// limited to 16 colors or fewer rarely support underline colors, so none is emitted for them.
+func (f *Format) ulShort(level ColorLevel) string {
+	if f.ulx == nil || level < LevelANSI256 {
+		return ""
+	}
+	return f.ulx.ulShortAt(level)
 }
 
 func (f *Format) String() string {
@@ -137,7 +347,7 @@ func (f *Format) String() string {
 }
 
 func (f *Format) Set() {
-	fmt.Print(f.String())
+	fmt.Print(f.Render(GetProfile()))
 }
 
 func (f *Format) Reset() {
@@ -145,11 +355,12 @@ func (f *Format) Reset() {
 }
 
 func (f *Format) Wrap(s string, reset bool) string {
+	p := GetProfile()
 	var b strings.Builder
-	b.WriteString(f.String())
+	b.WriteString(f.Render(p))
 	b.WriteString(s)
 	if reset {
-		b.WriteString(GetDefaultFormat().String())
+		b.WriteString(GetDefaultFormat().Render(p))
 	}
 	return b.String()
 }
@@ -0,0 +1,44 @@
+package ansicolor
+
+import "testing"
+
+func TestFormatString(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Format
+		want string
+	}{
+		{name: "empty", f: NewFormat(), want: ""},
+		{name: "foreground", f: NewFormat().WithForeground(FgRed), want: "\033[31;22;23;24;25;27;28;29m"},
+		{name: "option", f: NewFormat().WithOption(SGROptBold), want: "\033[1m"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.String(); got != tt.want {
+			t.Errorf("%s: String() = %q, want %q", tt.name, got, tt.want)
+		}
+		if got := tt.f.Render(ProfileTrueColor); got != tt.want {
+			t.Errorf("%s: Render(ProfileTrueColor) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRender(t *testing.T) {
+	f := NewFormat().WithForegroundColor(TrueColor(0xff, 0x87, 0x00)).WithOption(SGROptItalic)
+	tests := []struct {
+		p    Profile
+		want string
+	}{
+		{p: ProfileTrueColor, want: "\033[38;2;255;135;0;3m"},
+		{p: Profile{Colors: LevelANSI256, Options: SGROptAll}, want: "\033[38;5;208;3m"},
+		{p: Profile{Colors: LevelANSI256}, want: "\033[38;5;208m"},
+		{p: ProfilePlain, want: ""},
+	}
+	for _, tt := range tests {
+		if got := f.Render(tt.p); got != tt.want {
+			t.Errorf("Render(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+	if got := NewFormat().Render(ProfileTrueColor); got != "" {
+		t.Errorf("empty Format: Render() = %q, want \"\"", got)
+	}
+}
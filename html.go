@@ -0,0 +1,292 @@
+package ansicolor
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// defaultClassPrefix is the prefix of the CSS classes emitted by an HTMLConverter without a ClassPrefix.
+const defaultClassPrefix = "ansi-"
+
+// htmlLinkSchemes lists the URL schemes of hyperlinks that are converted to HTML links. Links with other schemes,
+// such as javascript:, are dropped and only their text is kept.
+var htmlLinkSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+	"ftp":    true,
+}
+
+// htmlOptionClasses maps SGR options to the suffix of their CSS class. Reverse video has no class: the swapped
+// colors are always written as inline styles.
+var htmlOptionClasses = map[SGROption]string{
+	SGROptBold:            "bold",
+	SGROptFaint:           "faint",
+	SGROptItalic:          "italic",
+	SGROptUnderline:       "underline",
+	SGROptBlink:           "blink",
+	SGROptFastBlink:       "blink",
+	SGROptConceal:         "conceal",
+	SGROptStrike:          "strike",
+	SGROptDoubleUnderline: "double-underline",
+}
+
+// HTMLConverter converts text containing escape sequences, such as colored command output, to HTML. Text is
+// HTML-escaped and wrapped in <span> elements carrying its colors and styles, and OSC 8 hyperlinks become <a>
+// elements. Other escape sequences and control characters except newlines and tabs are dropped.
+//
+// The result is an HTML fragment meant to be placed in a <pre> element or another element that preserves white
+// space. Blinking text only blinks with Classes: its animation is defined by the style sheet of CSS, as inline
+// styles cannot define one.
+type HTMLConverter struct {
+	// Palette defines the RGB values of the standard and 256-color palette colors. DefaultPalette is used if nil.
+	Palette *Palette
+	// Classes emits CSS classes instead of inline styles where possible; see CSS for the matching style sheet.
+	// RGB colors, the swapped colors of reverse video and underline colors are always emitted as inline styles.
+	Classes bool
+	// ClassPrefix is the prefix of every CSS class, "ansi-" if empty.
+	ClassPrefix string
+}
+
+// ToHTML converts s to HTML with inline styles and the default palette. See HTMLConverter.
+func ToHTML(s string) string {
+	return HTMLConverter{}.Convert(s)
+}
+
+// Convert converts s to an HTML fragment.
+func (c HTMLConverter) Convert(s string) string {
+	var b strings.Builder
+	style := newTextStyle()
+	open, link := NewFormat(), ""
+	spanOpen := false
+	closeSpan := func() {
+		if spanOpen {
+			b.WriteString("</span>")
+			spanOpen = false
+		}
+		open = NewFormat()
+	}
+	for _, tok := range Tokenize(s) {
+		if !keepPlain(tok) {
+			style.update(tok)
+			continue
+		}
+		if href := htmlLinkTarget(style.link); href != link {
+			closeSpan()
+			if link != "" {
+				b.WriteString("</a>")
+			}
+			if href != "" {
+				b.WriteString(`<a href="`)
+				b.WriteString(html.EscapeString(href))
+				b.WriteString(`">`)
+			}
+			link = href
+		}
+		if !open.Equal(style.format) {
+			closeSpan()
+			if tag := c.spanTag(style.format); tag != "" {
+				b.WriteString(tag)
+				spanOpen = true
+			}
+			open = style.format
+		}
+		b.WriteString(html.EscapeString(tok.Raw))
+	}
+	closeSpan()
+	if link != "" {
+		b.WriteString("</a>")
+	}
+	return b.String()
+}
+
+// CSS returns a style sheet defining the classes emitted with Classes set: the foreground (prefix "fg-") and
+// background (prefix "bg-") colors of the 256-color palette, indexed like the palette, and one class per style.
+// Blinking text is animated, so that it combines with underlined and struck-out text.
+func (c HTMLConverter) CSS() string {
+	prefix, pal := c.prefix(), c.palette()
+	var b strings.Builder
+	for i := 0; i < 256; i++ {
+		rgb := palette256RGB(uint8(i), pal)
+		fmt.Fprintf(&b, ".%sfg-%d { color: %s; }\n", prefix, i, rgb.Hex())
+		fmt.Fprintf(&b, ".%sbg-%d { background-color: %s; }\n", prefix, i, rgb.Hex())
+	}
+	rules := []struct{ class, decl string }{
+		{"bold", "font-weight: bold;"},
+		{"faint", "opacity: 0.5;"},
+		{"italic", "font-style: italic;"},
+		{"underline", "text-decoration-line: underline;"},
+		{"double-underline", "text-decoration-line: underline; text-decoration-style: double;"},
+		{"blink", "animation: " + prefix + "blink 1s step-end infinite;"},
+		{"strike", "text-decoration-line: line-through;"},
+		{"underline." + prefix + "strike", "text-decoration-line: underline line-through;"},
+		{"double-underline." + prefix + "strike", "text-decoration-line: underline line-through;"},
+		{"conceal", "visibility: hidden;"},
+	}
+	for _, r := range rules {
+		fmt.Fprintf(&b, ".%s%s { %s }\n", prefix, r.class, r.decl)
+	}
+	fmt.Fprintf(&b, "@keyframes %sblink { 50%% { visibility: hidden; } }\n", prefix)
+	return b.String()
+}
+
+// spanTag returns the opening <span> tag for text in the provided Format, or an empty string if the Format has
+// nothing to display in HTML.
+func (c HTMLConverter) spanTag(f *Format) string {
+	var classes, styles []string
+	if c.Classes {
+		classes, styles = c.formatClasses(f)
+	} else {
+		styles = htmlStyles(f, c.palette())
+	}
+	if len(classes) == 0 && len(styles) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<span")
+	if len(classes) > 0 {
+		b.WriteString(` class="`)
+		b.WriteString(strings.Join(classes, " "))
+		b.WriteString(`"`)
+	}
+	if len(styles) > 0 {
+		b.WriteString(` style="`)
+		b.WriteString(strings.Join(styles, ";"))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// formatClasses returns the CSS classes for a Format, along with the inline styles of the colors that have no
+// class: RGB colors, the colors of reverse video and the underline color.
+func (c HTMLConverter) formatClasses(f *Format) (classes, styles []string) {
+	prefix := c.prefix()
+	if f.opts.Has(SGROptReverse) {
+		fg, bg, _, _ := c.palette().FormatColors(f)
+		styles = append(styles, "color:"+fg.Hex(), "background-color:"+bg.Hex())
+	} else {
+		if class, style := htmlColorClass(prefix+"fg-", "color:", f.fgx, fgIndex(f.fg)); class != "" {
+			classes = append(classes, class)
+		} else if style != "" {
+			styles = append(styles, style)
+		}
+		if class, style := htmlColorClass(prefix+"bg-", "background-color:", f.bgx, bgIndex(f.bg)); class != "" {
+			classes = append(classes, class)
+		} else if style != "" {
+			styles = append(styles, style)
+		}
+	}
+	if f.ulx != nil && f.opts.HasAny(SGROptUnderline|SGROptDoubleUnderline) {
+		styles = append(styles, "text-decoration-color:"+c.palette().Color(*f.ulx).Hex())
+	}
+	seen := map[string]bool{}
+	for _, opt := range sgrOptOrder {
+		if name, ok := htmlOptionClasses[opt]; ok && f.opts.Has(opt) && !seen[name] {
+			seen[name] = true
+			classes = append(classes, prefix+name)
+		}
+	}
+	return classes, styles
+}
+
+// htmlColorClass returns the CSS class of a palette color, or the inline style of an RGB color.
+func htmlColorClass(classPrefix, property string, x *Color, index int) (class, style string) {
+	switch {
+	case x != nil && x.isRGB:
+		return "", property + x.rgb.Hex()
+	case x != nil:
+		return fmt.Sprintf("%s%d", classPrefix, x.index), ""
+	case index >= 0:
+		return fmt.Sprintf("%s%d", classPrefix, index), ""
+	}
+	return "", ""
+}
+
+// htmlStyles returns the inline CSS declarations for a Format.
+func htmlStyles(f *Format, pal *Palette) []string {
+	var styles []string
+	fg, bg, fgSet, bgSet := pal.FormatColors(f)
+	if fgSet {
+		styles = append(styles, "color:"+fg.Hex())
+	}
+	if bgSet {
+		styles = append(styles, "background-color:"+bg.Hex())
+	}
+	if f.opts.Has(SGROptBold) {
+		styles = append(styles, "font-weight:bold")
+	}
+	if f.opts.Has(SGROptFaint) {
+		styles = append(styles, "opacity:0.5")
+	}
+	if f.opts.Has(SGROptItalic) {
+		styles = append(styles, "font-style:italic")
+	}
+	var lines []string
+	if f.opts.HasAny(SGROptUnderline | SGROptDoubleUnderline) {
+		lines = append(lines, "underline")
+	}
+	if f.opts.Has(SGROptStrike) {
+		lines = append(lines, "line-through")
+	}
+	if len(lines) > 0 {
+		styles = append(styles, "text-decoration-line:"+strings.Join(lines, " "))
+	}
+	if f.opts.Has(SGROptDoubleUnderline) {
+		styles = append(styles, "text-decoration-style:double")
+	}
+	if f.ulx != nil && f.opts.HasAny(SGROptUnderline|SGROptDoubleUnderline) {
+		styles = append(styles, "text-decoration-color:"+pal.Color(*f.ulx).Hex())
+	}
+	if f.opts.Has(SGROptConceal) {
+		styles = append(styles, "visibility:hidden")
+	}
+	return styles
+}
+
+// htmlLinkTarget returns the URL of a raw OSC 8 hyperlink sequence if its scheme is safe to link to.
+func htmlLinkTarget(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	uri, _ := hyperlinkURI(Tokenize(raw)[0])
+	u, err := url.Parse(uri)
+	if err != nil || !htmlLinkSchemes[strings.ToLower(u.Scheme)] {
+		return ""
+	}
+	return uri
+}
+
+// fgIndex returns the palette index of an optional standard foreground color, or -1.
+func fgIndex(c *FgColor) int {
+	if c == nil {
+		return -1
+	}
+	return c.PaletteIndex()
+}
+
+// bgIndex returns the palette index of an optional standard background color, or -1.
+func bgIndex(c *BgColor) int {
+	if c == nil {
+		return -1
+	}
+	return c.PaletteIndex()
+}
+
+// palette returns the converter's palette.
+func (c HTMLConverter) palette() *Palette {
+	if c.Palette != nil {
+		return c.Palette
+	}
+	return &DefaultPalette
+}
+
+// prefix returns the converter's CSS class prefix.
+func (c HTMLConverter) prefix() string {
+	if c.ClassPrefix != "" {
+		return c.ClassPrefix
+	}
+	return defaultClassPrefix
+}
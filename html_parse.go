@@ -0,0 +1,364 @@
+package ansicolor
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// htmlTagOptions maps the HTML elements that style their content to the SGR options they stand for.
+var htmlTagOptions = map[string]SGROption{
+	"b":      SGROptBold,
+	"strong": SGROptBold,
+	"i":      SGROptItalic,
+	"em":     SGROptItalic,
+	"u":      SGROptUnderline,
+	"ins":    SGROptUnderline,
+	"s":      SGROptStrike,
+	"strike": SGROptStrike,
+	"del":    SGROptStrike,
+	"blink":  SGROptBlink,
+}
+
+// htmlVoidTags lists the HTML elements without content or closing tag.
+var htmlVoidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// htmlRawTags lists the HTML elements whose content is not text and is dropped.
+var htmlRawTags = map[string]bool{
+	"script": true,
+	"style":  true,
+}
+
+// cssColorNames holds the RGB values of the CSS color keywords that have no terminal color of the same name.
+// Keywords shared with terminal colors, such as red or cyan, are mapped to the terminal colors.
+var cssColorNames = map[string]RGB{
+	"gray":    {R: 128, G: 128, B: 128},
+	"grey":    {R: 128, G: 128, B: 128},
+	"silver":  {R: 192, G: 192, B: 192},
+	"maroon":  {R: 128, G: 0, B: 0},
+	"navy":    {R: 0, G: 0, B: 128},
+	"olive":   {R: 128, G: 128, B: 0},
+	"purple":  {R: 128, G: 0, B: 128},
+	"teal":    {R: 0, G: 128, B: 128},
+	"lime":    {R: 0, G: 255, B: 0},
+	"aqua":    {R: 0, G: 255, B: 255},
+	"fuchsia": {R: 255, G: 0, B: 255},
+	"orange":  {R: 255, G: 165, B: 0},
+}
+
+// htmlElement is an open HTML element.
+type htmlElement struct {
+	name   string
+	format *Format
+}
+
+// ParseHTML parses a fragment of HTML, such as the output of tools that color their reports for the web, into a
+// StyledText. The following subset of HTML is understood:
+//
+//   - <b>, <strong>, <i>, <em>, <u>, <ins>, <s>, <strike>, <del> and <blink> elements
+//   - <font color="..."> elements
+//   - style attributes of any element with the color, background-color, background, font-weight, font-style,
+//     text-decoration, text-decoration-line, text-decoration-style, text-decoration-color, opacity and
+//     visibility properties
+//   - <br> elements, which become newlines
+//
+// Colors may be hexadecimal (#rgb or #rrggbb), rgb() functions, terminal color names such as red or "bright blue"
+// and the basic CSS color keywords. Other elements, attributes and properties are ignored but their text is kept,
+// except for the content of <script> and <style> elements. Comments are dropped and character references are
+// decoded. White space is kept as is, as in a <pre> element, with line breaks normalized to "\n".
+//
+// So that imported HTML cannot write escape sequences to the terminal, control characters other than tab and
+// newline are dropped from the text, including those written as character references, and invalid UTF-8 is
+// replaced with U+FFFD.
+//
+// Parsing never fails: malformed markup is treated as text and unmatched closing tags are ignored.
+func ParseHTML(s string) *StyledText {
+	t := NewStyledText()
+	stack := []htmlElement{{format: NewFormat()}}
+	text := func(raw string) {
+		t.appendSpan(Span{Text: htmlText(raw), Format: stack[len(stack)-1].format})
+	}
+	for s != "" {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			text(s)
+			break
+		}
+		text(s[:lt])
+		s = s[lt:]
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				break
+			}
+			s = s[end+3:]
+			continue
+		}
+		name, attrs, closing, n := parseHTMLTag(s)
+		if n == 0 {
+			text("<")
+			s = s[1:]
+			continue
+		}
+		s = s[n:]
+		switch {
+		case closing:
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		case name == "br":
+			text("\n")
+		case htmlRawTags[name]:
+			end := indexEndTag(s, name)
+			if end < 0 {
+				return t
+			}
+			s = s[end:]
+		case !htmlVoidTags[name]:
+			stack = append(stack, htmlElement{name: name, format: htmlElementFormat(stack[len(stack)-1].format,
+				name, attrs)})
+		}
+	}
+	return t
+}
+
+// FromHTML converts a fragment of HTML to text with escape sequences for the global Profile. See ParseHTML.
+func FromHTML(s string) string {
+	return ParseHTML(s).Render(GetProfile())
+}
+
+// htmlNewlines normalizes the line breaks of HTML text to "\n".
+var htmlNewlines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// htmlText decodes the character references of HTML text, normalizes its line breaks, drops the control characters
+// other than tab and newline and replaces invalid UTF-8 with U+FFFD.
+func htmlText(raw string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && (r < 0x20 || r >= 0x7f && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, htmlNewlines.Replace(html.UnescapeString(raw)))
+}
+
+// indexEndTag returns the index of the first "</name" in s, matching the lower-case name without regard to the
+// case of ASCII letters, or -1 if there is none. Other characters are compared as is, so that indexes into s are
+// not shifted by case conversions changing the length of non-ASCII characters.
+func indexEndTag(s, name string) int {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "</")
+		if j < 0 {
+			return -1
+		}
+		j += i + 2
+		if j+len(name) <= len(s) && equalFoldASCII(s[j:j+len(name)], name) {
+			return j - 2
+		}
+		i = j
+	}
+}
+
+// equalFoldASCII reports whether s and lower are equal, lower being in lower case, ignoring the case of ASCII
+// letters in s.
+func equalFoldASCII(s, lower string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != lower[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseHTMLTag parses the tag at the start of s. It returns the lower-case name of the element, its attributes,
+// whether it is a closing tag and the length of the tag, which is 0 if s does not start with a tag.
+func parseHTMLTag(s string) (name string, attrs map[string]string, closing bool, n int) {
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		closing = true
+		i++
+	}
+	start := i
+	for i < len(s) && (isWordByte(s[i]) || i > start && s[i] == '-') {
+		i++
+	}
+	if i == start || i == len(s) || !strings.ContainsRune(" \t\r\n/>", rune(s[i])) {
+		return "", nil, false, 0
+	}
+	name = strings.ToLower(s[start:i])
+	attrs = map[string]string{}
+	for i < len(s) {
+		for i < len(s) && strings.ContainsRune(" \t\r\n/", rune(s[i])) {
+			i++
+		}
+		if i == len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return name, attrs, closing, i + 1
+		}
+		key := i
+		for i < len(s) && !strings.ContainsRune(" \t\r\n/>=", rune(s[i])) {
+			i++
+		}
+		attr := strings.ToLower(s[key:i])
+		for i < len(s) && strings.ContainsRune(" \t\r\n", rune(s[i])) {
+			i++
+		}
+		if i == len(s) || s[i] != '=' {
+			attrs[attr] = ""
+			continue
+		}
+		for i++; i < len(s) && strings.ContainsRune(" \t\r\n", rune(s[i])); i++ {
+		}
+		if i == len(s) {
+			break
+		}
+		if q := s[i]; q == '"' || q == '\'' {
+			end := strings.IndexByte(s[i+1:], q)
+			if end < 0 {
+				break
+			}
+			attrs[attr] = html.UnescapeString(s[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+		value := i
+		for i < len(s) && !strings.ContainsRune(" \t\r\n>", rune(s[i])) {
+			i++
+		}
+		attrs[attr] = html.UnescapeString(s[value:i])
+	}
+	return "", nil, false, 0
+}
+
+// htmlElementFormat returns the Format of the content of an element inside an element displayed with parent.
+func htmlElementFormat(parent *Format, name string, attrs map[string]string) *Format {
+	f := parent
+	if opt, ok := htmlTagOptions[name]; ok {
+		f = f.WithOption(opt)
+	}
+	if color, ok := attrs["color"]; ok && name == "font" {
+		f = applyCSSColor(f, color, false)
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		property, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		f = applyCSSProperty(f, strings.ToLower(strings.TrimSpace(property)),
+			strings.ToLower(strings.TrimSpace(value)))
+	}
+	return f
+}
+
+// applyCSSProperty applies a CSS declaration to f. Unknown properties and values are ignored.
+func applyCSSProperty(f *Format, property, value string) *Format {
+	value = strings.TrimSpace(strings.TrimSuffix(value, "!important"))
+	switch property {
+	case "color":
+		return applyCSSColor(f, value, false)
+	case "background-color", "background":
+		return applyCSSColor(f, value, true)
+	case "font-weight":
+		if weight, err := strconv.Atoi(value); value == "bold" || value == "bolder" || err == nil && weight >= 600 {
+			return f.WithOption(SGROptBold)
+		}
+	case "font-style":
+		if value == "italic" || strings.HasPrefix(value, "oblique") {
+			return f.WithOption(SGROptItalic)
+		}
+	case "text-decoration", "text-decoration-line", "text-decoration-style":
+		for _, word := range strings.Fields(value) {
+			switch word {
+			case "underline":
+				f = f.WithOption(SGROptUnderline)
+			case "line-through":
+				f = f.WithOption(SGROptStrike)
+			case "blink":
+				f = f.WithOption(SGROptBlink)
+			case "double":
+				if f.HasOption(SGROptUnderline) || property == "text-decoration-style" {
+					f = f.WithOption(SGROptDoubleUnderline)
+				}
+			}
+		}
+	case "text-decoration-color":
+		c := applyCSSColor(NewFormat(), value, true)
+		if ul := backgroundAsColor(c); ul != nil {
+			return f.WithUnderlineColor(*ul)
+		}
+	case "opacity":
+		if opacity, err := strconv.ParseFloat(value, 64); err == nil && opacity < 1 {
+			return f.WithOption(SGROptFaint)
+		}
+	case "visibility":
+		if value == "hidden" {
+			return f.WithOption(SGROptConceal)
+		}
+	}
+	return f
+}
+
+// applyCSSColor applies a CSS color value as the foreground or background color of f. Unknown colors are
+// ignored.
+func applyCSSColor(f *Format, value string, background bool) *Format {
+	value = strings.ToLower(strings.TrimSpace(value))
+	var c Color
+	if rgb, ok := parseCSSRGB(value); ok {
+		c = TrueColor(rgb.R, rgb.G, rgb.B)
+	} else if rgb, ok := cssColorNames[value]; ok {
+		c = TrueColor(rgb.R, rgb.G, rgb.B)
+	} else if _, ok := applyStyleName(NewFormat(), value, true); ok {
+		// A terminal color name, validated as a background color so that options are not accepted.
+		nf, _ := applyStyleName(f, value, background)
+		return nf
+	} else {
+		return f
+	}
+	if background {
+		return f.WithBackgroundColor(c)
+	}
+	return f.WithForegroundColor(c)
+}
+
+// parseCSSRGB parses a hexadecimal color or an rgb() or rgba() function with integer channels.
+func parseCSSRGB(value string) (RGB, bool) {
+	if strings.HasPrefix(value, "#") {
+		if len(value) != 4 && len(value) != 7 {
+			return RGB{}, false
+		}
+		rgb, err := ParseXColor(value)
+		return rgb, err == nil
+	}
+	args, ok := strings.CutPrefix(value, "rgb(")
+	if !ok {
+		args, ok = strings.CutPrefix(value, "rgba(")
+	}
+	args, closed := strings.CutSuffix(args, ")")
+	if !ok || !closed {
+		return RGB{}, false
+	}
+	fields := strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+	if len(fields) < 3 {
+		return RGB{}, false
+	}
+	var channels [3]uint8
+	for i := range channels {
+		v, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return RGB{}, false
+		}
+		channels[i] = uint8(min(max(v, 0), 255))
+	}
+	return RGB{R: channels[0], G: channels[1], B: channels[2]}, true
+}
@@ -0,0 +1,88 @@
+package ansicolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	bold := NewFormat().WithOption(SGROptBold)
+	red := NewFormat().WithForeground(FgRed)
+	tests := []struct {
+		in   string
+		want []Span
+	}{
+		{in: "plain &amp; &lt;text&gt;", want: []Span{{Text: "plain & <text>", Format: NewFormat()}}},
+		{in: "<b>bold</b> <I>italic</I>", want: []Span{
+			{Text: "bold", Format: bold},
+			{Text: " ", Format: NewFormat()},
+			{Text: "italic", Format: NewFormat().WithOption(SGROptItalic)},
+		}},
+		{in: `<font color="red">r<u>u</u></font>`, want: []Span{
+			{Text: "r", Format: red},
+			{Text: "u", Format: red.WithOption(SGROptUnderline)},
+		}},
+		{in: `<span style="color: #ff8800; background-color: rgb(0, 0, 255); font-weight: 700">x</span>`,
+			want: []Span{{Text: "x", Format: NewFormat().WithForegroundColor(TrueColor(0xff, 0x88, 0)).
+				WithBackgroundColor(TrueColor(0, 0, 255)).WithOption(SGROptBold)}}},
+		{in: `<span style="text-decoration: underline double; text-decoration-color: teal">x</span>`,
+			want: []Span{{Text: "x", Format: NewFormat().WithOption(SGROptUnderline | SGROptDoubleUnderline).
+				WithUnderlineColor(TrueColor(0, 128, 128))}}},
+		{in: `<span style="color: nonsense; unknown: red">x</span>`, want: []Span{{Text: "x", Format: NewFormat()}}},
+		{in: "a<br>b<br/>c", want: []Span{{Text: "a\nb\nc", Format: NewFormat()}}},
+		{in: "a<!-- <b>comment</b> -->b", want: []Span{{Text: "ab", Format: NewFormat()}}},
+		{in: "<b>a</i>b</b>c", want: []Span{{Text: "ab", Format: bold}, {Text: "c", Format: NewFormat()}}},
+		{in: "1 < 2 <> 3", want: []Span{{Text: "1 < 2 <> 3", Format: NewFormat()}}},
+		{in: "<b>unclosed", want: []Span{{Text: "unclosed", Format: bold}}},
+		{in: "a<script>if (a < b) x()</script>b", want: []Span{{Text: "ab", Format: NewFormat()}}},
+		{in: "a<STYLE>b { }</Style>c", want: []Span{{Text: "ac", Format: NewFormat()}}},
+		{in: "a<script>never closed", want: []Span{{Text: "a", Format: NewFormat()}}},
+	}
+	for _, tt := range tests {
+		if got := ParseHTML(tt.in); !equalSpans(got, tt.want) {
+			t.Errorf("ParseHTML(%q) = %s, want %s", tt.in, spansString(got.Spans()), spansString(tt.want))
+		}
+	}
+}
+
+// TestParseHTMLRawNonASCII checks the content of <script> and <style> elements holding characters whose case
+// conversion changes their length.
+func TestParseHTMLRawNonASCII(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "<script>" + strings.Repeat("Ⱥ", 10) + "</script>after", want: "after"},
+		{in: "<script>" + strings.Repeat("ȿ", 10) + "</SCRIPT>after", want: "after"},
+		{in: "<style>İ</style>İ", want: "İ"},
+		// Only ASCII letters fold: the long s and the Kelvin sign do not end the element.
+		{in: "<script></ſcript>x</script>y", want: "y"},
+		{in: "<style></Keep></style>z", want: "z"},
+	}
+	for _, tt := range tests {
+		if got := ParseHTML(tt.in).String(); got != tt.want {
+			t.Errorf("ParseHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseHTMLControls(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "&#27;]52;c;aGk=&#7;&#x1b;[2J", want: "]52;c;aGk=[2J"},
+		{in: "a\033[31mb\x9bc\u009bd\x7fe", want: "a[31mb�cde"},
+		{in: "&#x9b;1m&#127;&#0;x", want: "›1m�x"},
+		{in: "a\tb<br>c\r\nd\re", want: "a\tb\nc\nd\ne"},
+	}
+	for _, tt := range tests {
+		got := ParseHTML(tt.in)
+		if got.String() != tt.want {
+			t.Errorf("ParseHTML(%q) text = %q, want %q", tt.in, got.String(), tt.want)
+		}
+		if out := FromHTML(tt.in); out != tt.want {
+			t.Errorf("FromHTML(%q) = %q, want %q", tt.in, out, tt.want)
+		}
+	}
+}
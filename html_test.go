@@ -0,0 +1,66 @@
+package ansicolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "a < b & c", want: "a &lt; b &amp; c"},
+		{in: "\033[1;31mred\033[0m", want: `<span style="color:#cd0000;font-weight:bold">red</span>`},
+		{in: "\033[4;5;9mx", want: `<span style="text-decoration-line:underline line-through">x</span>`},
+		// Blinking needs the animation of the style sheet.
+		{in: "\033[5ma\033[6mb", want: "ab"},
+		{in: "\033[4;58;5;196mx",
+			want: `<span style="text-decoration-line:underline;text-decoration-color:#ff0000">x</span>`},
+		{in: "\033]8;;https://example.com/?a=1&b=2\033\\link\033]8;;\033\\",
+			want: `<a href="https://example.com/?a=1&amp;b=2">link</a>`},
+		{in: "\033]8;;javascript:alert(1)\033\\link\033]8;;\033\\", want: "link"},
+	}
+	for _, tt := range tests {
+		if got := ToHTML(tt.in); got != tt.want {
+			t.Errorf("ToHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLConverterClasses(t *testing.T) {
+	c := HTMLConverter{Classes: true}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "\033[1;31mred", want: `<span class="ansi-fg-1 ansi-bold">red</span>`},
+		{in: "\033[38;2;1;2;3;44mx", want: `<span class="ansi-bg-4" style="color:#010203">x</span>`},
+		{in: "\033[4;5mx", want: `<span class="ansi-underline ansi-blink">x</span>`},
+		{in: "\033[4;58;2;255;0;0mx", want: `<span class="ansi-underline" style="text-decoration-color:#ff0000">x</span>`},
+		{in: "\033[58;2;255;0;0mx", want: "x"},
+	}
+	for _, tt := range tests {
+		if got := c.Convert(tt.in); got != tt.want {
+			t.Errorf("Convert(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLConverterCSS(t *testing.T) {
+	css := HTMLConverter{ClassPrefix: "t-"}.CSS()
+	for _, rule := range []string{
+		".t-fg-1 { color: #cd0000; }",
+		".t-bg-255 { background-color: #eeeeee; }",
+		".t-underline { text-decoration-line: underline; }",
+		".t-blink { animation: t-blink 1s step-end infinite; }",
+		"@keyframes t-blink { 50% { visibility: hidden; } }",
+	} {
+		if !strings.Contains(css, rule) {
+			t.Errorf("CSS() lacks %q", rule)
+		}
+	}
+	if strings.Contains(css, "text-decoration-line: blink") {
+		t.Error("CSS() blinks with text-decoration-line, which overrides underline and line-through")
+	}
+}
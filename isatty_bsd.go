@@ -0,0 +1,16 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package ansicolor
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal reports whether the file descriptor refers to a terminal, that is whether it has terminal
+// attributes.
+func isTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGETA, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,14 @@
+package ansicolor
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal reports whether the file descriptor refers to a terminal, that is whether it has terminal
+// attributes.
+func isTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
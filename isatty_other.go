@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package ansicolor
+
+// isTerminal reports whether the file descriptor refers to a terminal. Terminals cannot be detected on this
+// platform, so it always reports false.
+func isTerminal(fd uintptr) bool {
+	return false
+}
@@ -0,0 +1,11 @@
+package ansicolor
+
+import (
+	"syscall"
+)
+
+// isTerminal reports whether the file descriptor refers to a console.
+func isTerminal(fd uintptr) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(fd), &mode) == nil
+}
@@ -0,0 +1,522 @@
+package ansicolor
+
+import (
+	"unicode/utf8"
+)
+
+// TokenKind identifies the kind of a Token produced by the Lexer.
+type TokenKind int
+
+// TokenText is a run of printable text.
+// TokenControl is a C0 or C1 control character that does not introduce a sequence, such as "\n" or BEL.
+// TokenESC is an escape sequence: ESC, optional intermediate bytes and a final byte, such as "\0337".
+// TokenCSI is a Control Sequence Introducer sequence, such as "\033[1;31m".
+// TokenOSC is an Operating System Command, such as "\033]8;;https://example.com\033\\".
+// TokenDCS is a Device Control String, such as "\033P+q544e\033\\".
+// TokenSOS is a Start Of String control string.
+// TokenPM is a Privacy Message control string.
+// TokenAPC is an Application Program Command control string.
+// TokenSS2 is a Single Shift 2 followed by the character it applies to.
+// TokenSS3 is a Single Shift 3 followed by the character it applies to.
+// TokenInvalid holds the bytes of a malformed, aborted or unterminated sequence.
+const (
+	TokenText TokenKind = iota
+	TokenControl
+	TokenESC
+	TokenCSI
+	TokenOSC
+	TokenDCS
+	TokenSOS
+	TokenPM
+	TokenAPC
+	TokenSS2
+	TokenSS3
+	TokenInvalid
+)
+
+// String returns the name of the TokenKind.
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(tokenKindNames) {
+		return ""
+	}
+	return tokenKindNames[k]
+}
+
+// tokenKindNames holds the names of the token kinds, indexed by TokenKind.
+var tokenKindNames = [...]string{
+	TokenText:    "text",
+	TokenControl: "control",
+	TokenESC:     "ESC",
+	TokenCSI:     "CSI",
+	TokenOSC:     "OSC",
+	TokenDCS:     "DCS",
+	TokenSOS:     "SOS",
+	TokenPM:      "PM",
+	TokenAPC:     "APC",
+	TokenSS2:     "SS2",
+	TokenSS3:     "SS3",
+	TokenInvalid: "invalid",
+}
+
+// Token is a piece of terminal output: a run of text, a control character or a complete escape sequence.
+//
+// Raw always holds the exact input bytes of the token. The other fields break sequences down:
+//   - CSI and DCS: Prefix is the private marker ('<', '=', '>' or '?', 0 if absent), Params the parameter bytes
+//     (digits, ':' and ';'), Intermediates the intermediate bytes and Final the final byte.
+//   - ESC: Intermediates and Final.
+//   - OSC, DCS, SOS, PM and APC: Data is the payload without introducer, header and terminator.
+//   - SS2 and SS3: Data is the character the shift applies to.
+//   - Control: Final is the control character (the C1 code point for C1 controls).
+type Token struct {
+	Kind          TokenKind
+	Raw           string
+	Prefix        byte
+	Params        string
+	Intermediates string
+	Final         byte
+	Data          string
+}
+
+// String returns the raw bytes of the Token.
+func (t Token) String() string {
+	return t.Raw
+}
+
+// IsSGR reports whether the Token is a Select Graphic Rendition sequence, whose parameters are in Params.
+func (t Token) IsSGR() bool {
+	return t.Kind == TokenCSI && t.Final == 'm' && t.Prefix == 0 && t.Intermediates == ""
+}
+
+// escStringKinds maps the final bytes of the escape sequences that introduce control strings to their kinds.
+var escStringKinds = map[byte]TokenKind{
+	']': TokenOSC,
+	'X': TokenSOS,
+	'^': TokenPM,
+	'_': TokenAPC,
+}
+
+// lexState is a state of the Lexer state machine.
+type lexState int
+
+// Lexer states. The CSI and DCS header states cover the entry, parameter and intermediate states of the VT
+// parser, with the phase field telling them apart.
+const (
+	lexGround lexState = iota
+	lexGroundC1
+	lexEscape
+	lexEscapeIntermediate
+	lexHeader
+	lexHeaderIgnore
+	lexString
+	lexStringEscape
+	lexStringC1
+	lexSingleShift
+)
+
+// Phases of a CSI or DCS header: nothing read yet, reading parameters, reading intermediate bytes.
+const (
+	phaseEntry = iota
+	phaseParams
+	phaseIntermediates
+)
+
+// Lexer splits terminal output into Tokens following the state machine of DEC-compatible terminals
+// (Paul Flo Williams' VT500 parser), extended with colon sub-parameters and UTF-8 input.
+//
+// The Lexer is streaming: input is written in arbitrary chunks and tokens are passed to the emit function as
+// soon as they are complete. Text is emitted at the end of each Write, never splitting a UTF-8 character.
+// C1 controls are recognized in their UTF-8 encoding (U+0080 to U+009F); other non-ASCII bytes are text.
+// C0 controls occurring inside a sequence are executed by terminals without interrupting it; they are kept in
+// the Raw field of the sequence but left out of its other fields. Concatenating the Raw fields of the emitted
+// tokens always reproduces the input exactly.
+type Lexer struct {
+	emit   func(Token)
+	state  lexState
+	kind   TokenKind
+	phase  int
+	ignore bool
+	text   []byte
+	raw    []byte
+	prefix byte
+	params []byte
+	inter  []byte
+	final  byte
+	data   []byte
+}
+
+// NewLexer creates a new Lexer passing every token to emit.
+func NewLexer(emit func(Token)) *Lexer {
+	return &Lexer{emit: emit}
+}
+
+// Tokenize splits s into Tokens.
+func Tokenize(s string) []Token {
+	var tokens []Token
+	l := NewLexer(func(t Token) {
+		tokens = append(tokens, t)
+	})
+	_, _ = l.WriteString(s)
+	_ = l.Close()
+	return tokens
+}
+
+// Write feeds p to the Lexer. It always consumes all of p and never fails.
+func (l *Lexer) Write(p []byte) (int, error) {
+	for _, b := range p {
+		l.step(b)
+	}
+	l.flushText(false)
+	return len(p), nil
+}
+
+// WriteString feeds s to the Lexer. It always consumes all of s and never fails.
+func (l *Lexer) WriteString(s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		l.step(s[i])
+	}
+	l.flushText(false)
+	return len(s), nil
+}
+
+// Close signals the end of the input, emitting any pending text and reporting an unfinished sequence as
+// TokenInvalid. The Lexer can be reused afterwards.
+func (l *Lexer) Close() error {
+	switch l.state {
+	case lexGround:
+	case lexGroundC1:
+		l.text = append(l.text, 0xc2)
+	default:
+		l.flushText(true)
+		l.invalid()
+	}
+	l.state = lexGround
+	l.flushText(true)
+	return nil
+}
+
+// step advances the state machine by one input byte.
+func (l *Lexer) step(b byte) {
+	switch l.state {
+	case lexGround:
+		l.stepGround(b)
+	case lexGroundC1:
+		l.state = lexGround
+		if b >= 0x80 && b <= 0x9f {
+			l.flushText(true)
+			l.raw = append(l.raw[:0], 0xc2, b)
+			l.c1(b)
+			return
+		}
+		l.text = append(l.text, 0xc2)
+		l.step(b)
+	case lexEscape, lexEscapeIntermediate:
+		l.stepEscape(b)
+	case lexHeader, lexHeaderIgnore:
+		l.stepHeader(b)
+	case lexString:
+		l.stepString(b)
+	case lexStringEscape:
+		if b == '\\' {
+			l.raw = append(l.raw, b)
+			l.dispatchString()
+			return
+		}
+		// Any other escape ends the string unterminated and starts a new sequence.
+		l.raw = l.raw[:len(l.raw)-1]
+		l.dispatchString()
+		l.startEscape()
+		l.step(b)
+	case lexStringC1:
+		if b == 0x9c {
+			l.raw = append(l.raw, b)
+			l.dispatchString()
+			return
+		}
+		l.state = lexString
+		if !l.ignore {
+			l.data = append(l.data, 0xc2)
+		}
+		l.step(b)
+	case lexSingleShift:
+		l.stepSingleShift(b)
+	}
+}
+
+// stepGround handles a byte outside of any sequence.
+func (l *Lexer) stepGround(b byte) {
+	switch {
+	case b == 0x1b:
+		l.flushText(true)
+		l.startEscape()
+	case b < 0x20 || b == 0x7f:
+		l.flushText(true)
+		l.emit(Token{Kind: TokenControl, Raw: string([]byte{b}), Final: b})
+	case b == 0xc2:
+		l.state = lexGroundC1
+	default:
+		l.text = append(l.text, b)
+	}
+}
+
+// stepEscape handles a byte following ESC or one of its intermediate bytes.
+func (l *Lexer) stepEscape(b byte) {
+	if l.interrupted(b) {
+		return
+	}
+	switch {
+	case b >= 0x20 && b <= 0x2f:
+		l.raw = append(l.raw, b)
+		l.inter = append(l.inter, b)
+		l.state = lexEscapeIntermediate
+	case b == 0x7f:
+		l.raw = append(l.raw, b)
+	case b >= 0x80:
+		l.invalid()
+		l.step(b)
+	case l.state == lexEscape && b == '[':
+		l.raw = append(l.raw, b)
+		l.startHeader(TokenCSI)
+	case l.state == lexEscape && b == 'P':
+		l.raw = append(l.raw, b)
+		l.startHeader(TokenDCS)
+	case l.state == lexEscape && (b == ']' || b == 'X' || b == '^' || b == '_'):
+		l.raw = append(l.raw, b)
+		l.startString(escStringKinds[b])
+	case l.state == lexEscape && (b == 'N' || b == 'O'):
+		l.raw = append(l.raw, b)
+		l.kind = TokenSS2
+		if b == 'O' {
+			l.kind = TokenSS3
+		}
+		l.data = l.data[:0]
+		l.state = lexSingleShift
+	default:
+		l.raw = append(l.raw, b)
+		l.final = b
+		l.dispatch(TokenESC)
+	}
+}
+
+// stepHeader handles a byte of the header of a CSI sequence or a DCS string.
+func (l *Lexer) stepHeader(b byte) {
+	if l.interrupted(b) {
+		return
+	}
+	if b >= 0x80 {
+		l.invalid()
+		l.step(b)
+		return
+	}
+	l.raw = append(l.raw, b)
+	switch {
+	case b >= 0x40 && b <= 0x7e:
+		l.final = b
+		switch {
+		case l.kind == TokenDCS:
+			// The DCS header is complete; the payload follows up to the string terminator.
+			l.ignore = l.state == lexHeaderIgnore
+			l.data = l.data[:0]
+			l.state = lexString
+		case l.state == lexHeaderIgnore:
+			l.invalid()
+		default:
+			l.dispatch(TokenCSI)
+		}
+		return
+	case l.state == lexHeaderIgnore || b == 0x7f:
+	case b >= '0' && b <= ';':
+		if l.phase == phaseIntermediates {
+			l.state = lexHeaderIgnore
+			return
+		}
+		l.params = append(l.params, b)
+		l.phase = phaseParams
+	case b >= '<' && b <= '?':
+		if l.phase != phaseEntry {
+			l.state = lexHeaderIgnore
+			return
+		}
+		l.prefix = b
+		l.phase = phaseParams
+	case b >= 0x20 && b <= 0x2f:
+		l.inter = append(l.inter, b)
+		l.phase = phaseIntermediates
+	}
+}
+
+// stepString handles a byte of the payload of an OSC, DCS, SOS, PM or APC string.
+func (l *Lexer) stepString(b byte) {
+	switch {
+	case b == 0x18 || b == 0x1a:
+		l.abort(b)
+	case b == 0x1b:
+		l.raw = append(l.raw, b)
+		l.state = lexStringEscape
+	case b == 0x07 && l.kind == TokenOSC:
+		l.raw = append(l.raw, b)
+		l.dispatchString()
+	case b == 0xc2:
+		l.raw = append(l.raw, b)
+		l.state = lexStringC1
+	case b < 0x20 || l.ignore:
+		// Other C0 controls are ignored inside strings.
+		l.raw = append(l.raw, b)
+	default:
+		l.raw = append(l.raw, b)
+		l.data = append(l.data, b)
+	}
+}
+
+// stepSingleShift collects the character following SS2 or SS3.
+func (l *Lexer) stepSingleShift(b byte) {
+	if len(l.data) == 0 && (b < 0x20 || b == 0x7f) {
+		if l.interrupted(b) {
+			return
+		}
+		l.invalid()
+		l.step(b)
+		return
+	}
+	l.raw = append(l.raw, b)
+	l.data = append(l.data, b)
+	if utf8.FullRune(l.data) {
+		l.dispatch(l.kind)
+	}
+}
+
+// interrupted handles the bytes that interrupt a sequence header: CAN and SUB abort it, ESC restarts it and
+// other C0 controls are executed without affecting it. It reports whether b was consumed.
+func (l *Lexer) interrupted(b byte) bool {
+	switch {
+	case b == 0x18 || b == 0x1a:
+		l.abort(b)
+	case b == 0x1b:
+		l.invalid()
+		l.startEscape()
+	case b < 0x20:
+		l.raw = append(l.raw, b)
+	default:
+		return false
+	}
+	return true
+}
+
+// c1 handles a C1 control character received in its UTF-8 encoding, whose bytes are already in raw.
+func (l *Lexer) c1(b byte) {
+	switch b {
+	case 0x9b:
+		l.startHeader(TokenCSI)
+	case 0x90:
+		l.startHeader(TokenDCS)
+	case 0x9d:
+		l.startString(TokenOSC)
+	case 0x98:
+		l.startString(TokenSOS)
+	case 0x9e:
+		l.startString(TokenPM)
+	case 0x9f:
+		l.startString(TokenAPC)
+	case 0x8e, 0x8f:
+		l.kind = TokenSS2
+		if b == 0x8f {
+			l.kind = TokenSS3
+		}
+		l.data = l.data[:0]
+		l.state = lexSingleShift
+	default:
+		l.final = b
+		l.dispatch(TokenControl)
+	}
+}
+
+// startEscape begins an escape sequence with the ESC byte.
+func (l *Lexer) startEscape() {
+	l.reset()
+	l.raw = append(l.raw, 0x1b)
+	l.state = lexEscape
+}
+
+// startHeader begins the header of a CSI sequence or DCS string.
+func (l *Lexer) startHeader(kind TokenKind) {
+	l.kind = kind
+	l.phase = phaseEntry
+	l.state = lexHeader
+}
+
+// startString begins an OSC, SOS, PM or APC string.
+func (l *Lexer) startString(kind TokenKind) {
+	l.kind = kind
+	l.data = l.data[:0]
+	l.state = lexString
+}
+
+// dispatchString emits the control string in progress, or an invalid token if its header was malformed.
+func (l *Lexer) dispatchString() {
+	if l.ignore {
+		l.invalid()
+		return
+	}
+	l.dispatch(l.kind)
+}
+
+// dispatch emits the sequence in progress as a token of the provided kind and returns to the ground state.
+func (l *Lexer) dispatch(kind TokenKind) {
+	l.emit(Token{
+		Kind:          kind,
+		Raw:           string(l.raw),
+		Prefix:        l.prefix,
+		Params:        string(l.params),
+		Intermediates: string(l.inter),
+		Final:         l.final,
+		Data:          string(l.data),
+	})
+	l.reset()
+}
+
+// invalid emits the bytes of the sequence in progress as TokenInvalid and returns to the ground state.
+func (l *Lexer) invalid() {
+	if len(l.raw) > 0 {
+		l.emit(Token{Kind: TokenInvalid, Raw: string(l.raw)})
+	}
+	l.reset()
+}
+
+// abort cancels the sequence in progress because of CAN or SUB, which is then emitted as a control token.
+func (l *Lexer) abort(b byte) {
+	l.invalid()
+	l.emit(Token{Kind: TokenControl, Raw: string([]byte{b}), Final: b})
+}
+
+// reset clears the sequence in progress and returns to the ground state.
+func (l *Lexer) reset() {
+	l.state = lexGround
+	l.ignore = false
+	l.raw = l.raw[:0]
+	l.prefix = 0
+	l.params = l.params[:0]
+	l.inter = l.inter[:0]
+	l.final = 0
+	l.data = l.data[:0]
+}
+
+// flushText emits the pending text. Unless all is set, an incomplete UTF-8 character at the end is kept back
+// until the next write completes it.
+func (l *Lexer) flushText(all bool) {
+	n := len(l.text)
+	if !all {
+		// Find the start of the last character and hold it back if it is incomplete.
+		for i := n - 1; i >= 0 && i >= n-utf8.UTFMax; i-- {
+			if utf8.RuneStart(l.text[i]) {
+				if !utf8.FullRune(l.text[i:]) {
+					n = i
+				}
+				break
+			}
+		}
+	}
+	if n == 0 {
+		return
+	}
+	l.emit(Token{Kind: TokenText, Raw: string(l.text[:n])})
+	l.text = append(l.text[:0], l.text[n:]...)
+}
@@ -0,0 +1,103 @@
+package ansicolor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Token
+	}{
+		{in: "plain", want: []Token{{Kind: TokenText, Raw: "plain"}}},
+		{in: "a\nb", want: []Token{
+			{Kind: TokenText, Raw: "a"},
+			{Kind: TokenControl, Raw: "\n", Final: '\n'},
+			{Kind: TokenText, Raw: "b"},
+		}},
+		{in: "\033[1;31mx", want: []Token{
+			{Kind: TokenCSI, Raw: "\033[1;31m", Params: "1;31", Final: 'm'},
+			{Kind: TokenText, Raw: "x"},
+		}},
+		{in: "\033[?25l", want: []Token{{Kind: TokenCSI, Raw: "\033[?25l", Prefix: '?', Params: "25", Final: 'l'}}},
+		{in: "\033[38:2::1:2:3m", want: []Token{{Kind: TokenCSI, Raw: "\033[38:2::1:2:3m", Params: "38:2::1:2:3",
+			Final: 'm'}}},
+		{in: "\033[1\n;2m", want: []Token{{Kind: TokenCSI, Raw: "\033[1\n;2m", Params: "1;2", Final: 'm'}}},
+		{in: "\u009b4m", want: []Token{{Kind: TokenCSI, Raw: "\u009b4m", Params: "4", Final: 'm'}}},
+		{in: "\0337", want: []Token{{Kind: TokenESC, Raw: "\0337", Final: '7'}}},
+		{in: "\033(B", want: []Token{{Kind: TokenESC, Raw: "\033(B", Intermediates: "(", Final: 'B'}}},
+		{in: "\033]8;;https://example.com\033\\", want: []Token{{Kind: TokenOSC, Raw: "\033]8;;https://example.com\033\\",
+			Data: "8;;https://example.com"}}},
+		{in: "\033]0;title\a", want: []Token{{Kind: TokenOSC, Raw: "\033]0;title\a", Data: "0;title"}}},
+		{in: "\033P+q544e\033\\", want: []Token{{Kind: TokenDCS, Raw: "\033P+q544e\033\\", Intermediates: "+",
+			Final: 'q', Data: "544e"}}},
+		{in: "\033_Gf=24\033\\", want: []Token{{Kind: TokenAPC, Raw: "\033_Gf=24\033\\", Data: "Gf=24"}}},
+		{in: "\033Né", want: []Token{{Kind: TokenSS2, Raw: "\033Né", Data: "é"}}},
+		{in: "\033[1\x18x", want: []Token{
+			{Kind: TokenInvalid, Raw: "\033[1"},
+			{Kind: TokenControl, Raw: "\x18", Final: 0x18},
+			{Kind: TokenText, Raw: "x"},
+		}},
+		{in: "\033[1;", want: []Token{{Kind: TokenInvalid, Raw: "\033[1;"}}},
+		{in: "\033[1\033[2m", want: []Token{
+			{Kind: TokenInvalid, Raw: "\033[1"},
+			{Kind: TokenCSI, Raw: "\033[2m", Params: "2", Final: 'm'},
+		}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestLexerChunks checks that the tokens do not depend on how the input is split into writes, apart from text
+// runs, which are emitted at the end of each write.
+func TestLexerChunks(t *testing.T) {
+	in := "ab\033[1;3\n1mcd\033]8;;http://x\033\\é\u009b0m\033P1$r0m\033\\"
+	var got []Token
+	l := NewLexer(func(t Token) {
+		if n := len(got); n > 0 && t.Kind == TokenText && got[n-1].Kind == TokenText {
+			got[n-1].Raw += t.Raw
+			return
+		}
+		got = append(got, t)
+	})
+	for i := 0; i < len(in); i++ {
+		_, _ = l.Write([]byte{in[i]})
+	}
+	_ = l.Close()
+	if want := Tokenize(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("byte by byte: %+v, want %+v", got, want)
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	for _, seed := range []string{
+		"plain text",
+		"\033[1;31mred\033[0m",
+		"\033[38:2::255:0:0m\033[?1049h",
+		"\033]8;;https://example.com\033\\link\033]8;;\a",
+		"\033P+q544e\033\\\033_APC\033\\\033^PM\033\\\033XSOS\033\\",
+		"\u009b1m\u009d0;title\u009c",
+		"\033Nx\033O\xe2\x82\xac",
+		"\033[1\n;2\x18m\033[\033]\x1a",
+		"\xff\xc2\xc2\x9b",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		var b strings.Builder
+		for _, tok := range Tokenize(s) {
+			if tok.Raw == "" {
+				t.Fatalf("Tokenize(%q): empty token %+v", s, tok)
+			}
+			b.WriteString(tok.Raw)
+		}
+		if b.String() != s {
+			t.Fatalf("Tokenize(%q) reassembles to %q", s, b.String())
+		}
+	})
+}
@@ -0,0 +1,305 @@
+package ansicolor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidLSColors indicates a malformed LS_COLORS entry or dircolors database line.
+var ErrInvalidLSColors = errors.New("invalid LS_COLORS")
+
+// defaultLSColors holds the colors GNU ls uses when LS_COLORS is not set.
+const defaultLSColors = "di=01;34:ln=01;36:pi=33:so=01;35:do=01;35:bd=01;33:cd=01;33:ex=01;32:" +
+	"su=37;41:sg=30;43:st=37;44:ow=34;42:tw=30;42"
+
+// lsLinkTarget is the value of the ln entry that displays symbolic links like the files they point to.
+const lsLinkTarget = "target"
+
+// lsColorsTypes lists the file type indicators of LS_COLORS. The lc, rc and ec indicators hold the raw
+// sequences ls writes around file names and are kept only to be written back by String.
+var lsColorsTypes = map[string]bool{
+	"no": true, "fi": true, "rs": true, "di": true, "ln": true, "mh": true, "pi": true, "so": true, "do": true,
+	"bd": true, "cd": true, "or": true, "mi": true, "su": true, "sg": true, "ca": true, "tw": true, "ow": true,
+	"st": true, "ex": true, "lc": true, "rc": true, "ec": true,
+}
+
+// dircolorsKeywords maps the keywords of dircolors databases to LS_COLORS indicators.
+var dircolorsKeywords = map[string]string{
+	"NORMAL": "no", "NORM": "no", "FILE": "fi", "RESET": "rs", "DIR": "di", "LINK": "ln", "LNK": "ln",
+	"SYMLINK": "ln", "MULTIHARDLINK": "mh", "FIFO": "pi", "PIPE": "pi", "SOCK": "so", "DOOR": "do",
+	"BLK": "bd", "BLOCK": "bd", "CHR": "cd", "CHAR": "cd", "ORPHAN": "or", "MISSING": "mi", "SETUID": "su",
+	"SETGID": "sg", "CAPABILITY": "ca", "STICKY_OTHER_WRITABLE": "tw", "OTHER_WRITABLE": "ow", "STICKY": "st",
+	"EXEC": "ex", "LEFTCODE": "lc", "LEFT": "lc", "RIGHTCODE": "rc", "RIGHT": "rc", "ENDCODE": "ec", "END": "ec",
+}
+
+// dircolorsIgnored lists the keywords of dircolors databases that do not define colors.
+var dircolorsIgnored = map[string]bool{
+	"OPTIONS": true, "COLOR": true, "EIGHTBIT": true,
+}
+
+// lsColorsEntry is an entry of LS_COLORS: a file type indicator or a "*suffix" pattern and its SGR parameters.
+type lsColorsEntry struct {
+	key    string
+	value  string
+	format *Format
+}
+
+// LSColors holds the file colors of ls, as defined by the LS_COLORS environment variable or a dircolors
+// database, and finds the Format for a file from its name and mode.
+type LSColors struct {
+	entries    []lsColorsEntry    // in order of definition
+	types      map[string]*Format // file type indicators
+	linkTarget bool               // ln=target: symbolic links are displayed like their targets
+}
+
+// ParseLSColors parses the value of the LS_COLORS environment variable, a colon-separated list of entries such as
+// "di=01;34:ln=01;36:*.tar=01;31". Each entry maps a two-letter file type indicator or a "*suffix" pattern to SGR
+// parameters; "ln=target" displays symbolic links like the files they point to. Returns an error wrapping
+// ErrInvalidLSColors for a malformed entry or an unknown indicator.
+func ParseLSColors(s string) (*LSColors, error) {
+	c := &LSColors{types: map[string]*Format{}}
+	for _, entry := range strings.Split(s, ":") {
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: malformed entry %q", ErrInvalidLSColors, entry)
+		}
+		if err := c.define(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// ParseDircolors parses a dircolors database, the configuration format of the dircolors command, for a terminal
+// of the provided type (the value of TERM). Lines holding a keyword such as DIR or EXEC, a ".extension" or a
+// "*pattern" followed by SGR parameters define colors; arguments after the first are ignored, as are the OPTIONS,
+// COLOR and EIGHTBIT lines. TERM and COLORTERM lines form groups restricting the lines after them to the matching
+// terminal types, until the next group. COLORTERM lines are kept in their group but never match, since only the
+// terminal type is provided. Comments start with '#'. Errors report the line number and wrap ErrInvalidLSColors.
+func ParseDircolors(r io.Reader, term string) (*LSColors, error) {
+	c := &LSColors{types: map[string]*Format{}}
+	scanner := bufio.NewScanner(r)
+	active, inTermGroup := true, false
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || dircolorsIgnored[strings.ToUpper(fields[0])] {
+			continue
+		}
+		if len(fields) < 2 {
+			err := fmt.Errorf("%w: expected a keyword and a value", ErrInvalidLSColors)
+			return nil, fmt.Errorf("dircolors: line %d: %w", n, err)
+		}
+		keyword, value := fields[0], fields[1]
+		switch strings.ToUpper(keyword) {
+		case "TERM", "COLORTERM":
+			if !inTermGroup {
+				active = false
+			}
+			if strings.EqualFold(keyword, "TERM") {
+				matched, _ := path.Match(value, term)
+				active = active || matched
+			}
+			inTermGroup = true
+			continue
+		}
+		inTermGroup = false
+		if !active {
+			continue
+		}
+		key := keyword
+		switch {
+		case strings.HasPrefix(keyword, "."):
+			key = "*" + keyword
+		case !strings.HasPrefix(keyword, "*"):
+			indicator, ok := dircolorsKeywords[strings.ToUpper(keyword)]
+			if !ok {
+				err := fmt.Errorf("%w: unknown keyword %q", ErrInvalidLSColors, keyword)
+				return nil, fmt.Errorf("dircolors: line %d: %w", n, err)
+			}
+			key = indicator
+		}
+		if err := c.define(key, value); err != nil {
+			return nil, fmt.Errorf("dircolors: line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// EnvLSColors parses the LS_COLORS environment variable, falling back on the default colors of GNU ls when it is
+// not set.
+func EnvLSColors() (*LSColors, error) {
+	s := os.Getenv("LS_COLORS")
+	if s == "" {
+		s = defaultLSColors
+	}
+	return ParseLSColors(s)
+}
+
+// define adds an entry, replacing an earlier definition of the same file type indicator.
+func (c *LSColors) define(key, value string) error {
+	pattern := strings.HasPrefix(key, "*")
+	switch {
+	case pattern && len(key) == 1:
+		return fmt.Errorf("%w: empty pattern in %s=%s", ErrInvalidLSColors, key, value)
+	case !pattern && !lsColorsTypes[key]:
+		return fmt.Errorf("%w: unknown file type indicator %q", ErrInvalidLSColors, key)
+	case key == "lc" || key == "rc" || key == "ec":
+		c.entries = append(c.entries, lsColorsEntry{key: key, value: value})
+		return nil
+	case key == "ln" && value == lsLinkTarget:
+		c.linkTarget = true
+		delete(c.types, key)
+		c.entries = append(c.entries, lsColorsEntry{key: key, value: value})
+		return nil
+	case strings.Trim(value, "0123456789;") != "":
+		return fmt.Errorf("%w: invalid SGR parameters in %s=%s", ErrInvalidLSColors, key, value)
+	}
+	f := NewFormat().ApplySGR(value)
+	if !pattern {
+		if key == "ln" {
+			c.linkTarget = false
+		}
+		c.types[key] = f
+	}
+	c.entries = append(c.entries, lsColorsEntry{key: key, value: value, format: f})
+	return nil
+}
+
+// String returns the colors in the LS_COLORS format, e.g. to export a parsed dircolors database.
+func (c *LSColors) String() string {
+	parts := make([]string, len(c.entries))
+	for i, e := range c.entries {
+		parts[i] = e.key + "=" + e.value
+	}
+	return strings.Join(parts, ":")
+}
+
+// Lookup returns the Format of a file from its name and mode, following the rules of GNU ls: directories and
+// regular files with special permissions use the st, ow, tw, su, sg and ex indicators when they are defined, the
+// "*suffix" patterns apply to the remaining regular files, and the last matching pattern wins, trying a
+// case-sensitive match first. Files without a matching entry use the "no" indicator, if defined.
+//
+// Symbolic links use the ln indicator, or are treated as regular files with "ln=target"; use LookupPath to detect
+// orphaned links and to display links like their targets.
+func (c *LSColors) Lookup(name string, mode fs.FileMode) *Format {
+	key := c.typeKey(mode)
+	if key == "fi" {
+		if f := c.patternFormat(name); f != nil {
+			return f
+		}
+	}
+	if f, ok := c.types[key]; ok {
+		return f
+	}
+	if f, ok := c.types["no"]; ok {
+		return f
+	}
+	return NewFormat()
+}
+
+// LookupPath returns the Format of a file in the file system, like Lookup. Symbolic links pointing to a missing
+// file use the or indicator, if defined; with "ln=target", other links are displayed like their targets, matching
+// the patterns against the name of the target.
+func (c *LSColors) LookupPath(name string) (*Format, error) {
+	info, err := os.Lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	mode, base := info.Mode(), filepath.Base(name)
+	if mode.Type() == fs.ModeSymlink {
+		target, err := os.Stat(name)
+		switch {
+		case err != nil:
+			if f, ok := c.types["or"]; ok {
+				return f, nil
+			}
+		case c.linkTarget:
+			// Like ls, match the patterns against the name of the target.
+			if link, err := os.Readlink(name); err == nil {
+				base = filepath.Base(link)
+			}
+			mode = target.Mode()
+		}
+	}
+	return c.Lookup(base, mode), nil
+}
+
+// typeKey returns the file type indicator of a file mode.
+func (c *LSColors) typeKey(mode fs.FileMode) string {
+	has := func(key string) bool {
+		_, ok := c.types[key]
+		return ok
+	}
+	otherWritable := mode.Perm()&0o002 != 0
+	sticky := mode&fs.ModeSticky != 0
+	switch mode.Type() {
+	case fs.ModeDir:
+		switch {
+		case sticky && otherWritable && has("tw"):
+			return "tw"
+		case otherWritable && has("ow"):
+			return "ow"
+		case sticky && has("st"):
+			return "st"
+		}
+		return "di"
+	case fs.ModeSymlink:
+		if c.linkTarget {
+			return "fi"
+		}
+		return "ln"
+	case fs.ModeNamedPipe:
+		return "pi"
+	case fs.ModeSocket:
+		return "so"
+	case fs.ModeDevice | fs.ModeCharDevice:
+		return "cd"
+	case fs.ModeDevice:
+		return "bd"
+	case 0:
+		switch {
+		case mode&fs.ModeSetuid != 0 && has("su"):
+			return "su"
+		case mode&fs.ModeSetgid != 0 && has("sg"):
+			return "sg"
+		case mode.Perm()&0o111 != 0 && has("ex"):
+			return "ex"
+		}
+		return "fi"
+	}
+	return "no"
+}
+
+// patternFormat returns the Format of the last "*suffix" pattern matching name, or nil if none matches.
+func (c *LSColors) patternFormat(name string) *Format {
+	for _, fold := range []bool{false, true} {
+		for i := len(c.entries) - 1; i >= 0; i-- {
+			e := c.entries[i]
+			if !strings.HasPrefix(e.key, "*") || len(name) < len(e.key)-1 {
+				continue
+			}
+			suffix, tail := e.key[1:], name[len(name)-len(e.key)+1:]
+			if tail == suffix || fold && strings.EqualFold(tail, suffix) {
+				return e.format
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package ansicolor
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestLSColorsLookup(t *testing.T) {
+	c, err := ParseLSColors("no=37:fi=0:di=01;34:ln=01;36:ex=01;32:su=37;41:sg=30;43:st=37;44:ow=34;42:tw=30;42:" +
+		"*.tar=01;31:*.TAR=35:*.md=33:*.gz=31:*.gz=36:*tar.gz=32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		mode fs.FileMode
+		want string
+	}{
+		// Special permissions take precedence over the patterns.
+		{"run.tar", 0o755, "1;32"},
+		{"run.tar", fs.ModeSetuid | 0o755, "37;41"},
+		{"run.tar", fs.ModeSetgid | 0o755, "30;43"},
+		{"run.tar", fs.ModeSetuid | fs.ModeSetgid | 0o755, "37;41"},
+		{"run.tar", 0o644, "1;31"},
+		// Directories: tw over ow over st.
+		{"tmp", fs.ModeDir | fs.ModeSticky | 0o777, "30;42"},
+		{"pub", fs.ModeDir | 0o777, "34;42"},
+		{"sticky", fs.ModeDir | fs.ModeSticky | 0o755, "37;44"},
+		{"dir.tar", fs.ModeDir | 0o755, "1;34"},
+		// A case-sensitive match comes before a case-insensitive one; otherwise the case is ignored.
+		{"a.TAR", 0o644, "35"},
+		{"a.Tar", 0o644, "35"},
+		{"a.tar", 0o644, "1;31"},
+		// The last matching pattern wins.
+		{"a.gz", 0o644, "36"},
+		{"a.tar.gz", 0o644, "32"},
+		// Files without a matching pattern use their type, then "no".
+		{"README", 0o644, "0"},
+		{"link", fs.ModeSymlink | 0o777, "1;36"},
+		{"fifo", fs.ModeNamedPipe | 0o644, "37"},
+	}
+	for _, tt := range tests {
+		if got := c.Lookup(tt.name, tt.mode); !got.Equal(NewFormat().ApplySGR(tt.want)) {
+			t.Errorf("Lookup(%q, %v) = %q, want %q", tt.name, tt.mode, got, NewFormat().ApplySGR(tt.want))
+		}
+	}
+}
+
+func TestLSColorsLookupUndefined(t *testing.T) {
+	// Without st, ow, tw, su, sg and ex, special files fall back on their type and patterns still apply.
+	c, err := ParseLSColors("di=34:*.sh=33")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		mode fs.FileMode
+		want *Format
+	}{
+		{"tmp", fs.ModeDir | fs.ModeSticky | 0o777, NewFormat().WithForeground(FgBlue)},
+		{"run.sh", fs.ModeSetuid | 0o755, NewFormat().WithForeground(FgYellow)},
+		{"run", 0o755, NewFormat()},
+		{"sh", 0o644, NewFormat()},
+	}
+	for _, tt := range tests {
+		if got := c.Lookup(tt.name, tt.mode); !got.Equal(tt.want) {
+			t.Errorf("Lookup(%q, %v) = %q, want %q", tt.name, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestLSColorsLinkTarget(t *testing.T) {
+	c, err := ParseLSColors("ln=01;36:*.txt=33:ln=target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Lookup("notes.txt", fs.ModeSymlink|0o777), NewFormat().WithForeground(FgYellow); !got.Equal(want) {
+		t.Errorf("Lookup() with ln=target = %q, want %q", got, want)
+	}
+	if got, want := c.String(), "ln=01;36:*.txt=33:ln=target"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestParseLSColorsErrors(t *testing.T) {
+	for _, s := range []string{"di", "=01", "xx=01", "*=01", "di=01;x", "*.gz=bold"} {
+		if _, err := ParseLSColors(s); !errors.Is(err, ErrInvalidLSColors) {
+			t.Errorf("ParseLSColors(%q) error = %v, want ErrInvalidLSColors", s, err)
+		}
+	}
+	c, err := ParseLSColors("::di=01;34::lc=\\e[:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.String(), "di=01;34:lc=\\e["; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+const testDircolors = `# Configuration file for dircolors
+COLORTERM ?*
+TERM xterm*
+TERM linux
+
+OPTIONS -F -T 0
+COLOR tty
+EIGHTBIT 1
+
+DIR 01;34 # directories
+link 01;36
+EXEC 01;32 extra arguments
+.tar 01;31
+*README 33
+
+TERM vt100
+DIR 07
+`
+
+func TestParseDircolors(t *testing.T) {
+	tests := []struct {
+		term string
+		want string
+	}{
+		{"xterm-256color", "di=01;34:ln=01;36:ex=01;32:*.tar=01;31:*README=33"},
+		{"linux", "di=01;34:ln=01;36:ex=01;32:*.tar=01;31:*README=33"},
+		{"vt100", "di=07"},
+		{"dumb", ""},
+	}
+	for _, tt := range tests {
+		c, err := ParseDircolors(strings.NewReader(testDircolors), tt.term)
+		if err != nil {
+			t.Errorf("ParseDircolors(%q) error = %v", tt.term, err)
+			continue
+		}
+		if got := c.String(); got != tt.want {
+			t.Errorf("ParseDircolors(%q) = %q, want %q", tt.term, got, tt.want)
+		}
+	}
+
+	// Without any TERM line, every line applies.
+	c, err := ParseDircolors(strings.NewReader("DIR 34\n.gz 31"), "dumb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Lookup("a.gz", 0o644), NewFormat().WithForeground(FgRed); !got.Equal(want) {
+		t.Errorf("Lookup() = %q, want %q", got, want)
+	}
+}
+
+func TestParseDircolorsErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		line string
+	}{
+		{"DIR 01;34\nEXEC\n", "line 2"},
+		{"# comment\n\nFROB 01\n", "line 3"},
+		{"DIR bold\n", "line 1"},
+	}
+	for _, tt := range tests {
+		_, err := ParseDircolors(strings.NewReader(tt.in), "xterm")
+		if !errors.Is(err, ErrInvalidLSColors) || !strings.Contains(err.Error(), tt.line) {
+			t.Errorf("ParseDircolors(%q) error = %v, want ErrInvalidLSColors at %s", tt.in, err, tt.line)
+		}
+	}
+}
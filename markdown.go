@@ -0,0 +1,669 @@
+package ansicolor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownTheme holds the Formats used to display the elements of a Markdown document. A nil Format displays the
+// element without any attributes.
+type MarkdownTheme struct {
+	Headings    [6]*Format // headings of levels 1 to 6
+	Emphasis    *Format    // *emphasis*
+	Strong      *Format    // **strong emphasis**
+	Strike      *Format    // ~~strike-through~~
+	Code        *Format    // `code spans`
+	CodeBlock   *Format    // fenced and indented code blocks
+	Link        *Format    // link text
+	LinkURL     *Format    // link destinations shown after the link text
+	BlockQuote  *Format    // the bar in front of block quotes
+	ListMarker  *Format    // bullets and numbers of list items
+	Rule        *Format    // thematic breaks
+	TableHeader *Format    // the header row of tables
+	TableBorder *Format    // the lines between table cells
+}
+
+// DefaultMarkdownTheme is the MarkdownTheme used by RenderMarkdown and by a MarkdownRenderer without a Theme.
+var DefaultMarkdownTheme = MarkdownTheme{
+	Headings: [6]*Format{
+		NewFormat().WithForeground(FgBrightMagenta).WithOption(SGROptBold | SGROptUnderline),
+		NewFormat().WithForeground(FgBrightBlue).WithOption(SGROptBold),
+		NewFormat().WithForeground(FgBrightCyan).WithOption(SGROptBold),
+		NewFormat().WithOption(SGROptBold),
+		NewFormat().WithOption(SGROptBold),
+		NewFormat().WithOption(SGROptBold | SGROptFaint),
+	},
+	Emphasis:    NewFormat().WithOption(SGROptItalic),
+	Strong:      NewFormat().WithOption(SGROptBold),
+	Strike:      NewFormat().WithOption(SGROptStrike),
+	Code:        NewFormat().WithForeground(FgYellow),
+	CodeBlock:   NewFormat().WithForeground(FgYellow),
+	Link:        NewFormat().WithForeground(FgBrightBlue).WithOption(SGROptUnderline),
+	LinkURL:     NewFormat().WithForeground(FgBrightBlack),
+	BlockQuote:  NewFormat().WithForeground(FgBrightBlack),
+	ListMarker:  NewFormat().WithForeground(FgCyan),
+	Rule:        NewFormat().WithForeground(FgBrightBlack),
+	TableHeader: NewFormat().WithOption(SGROptBold),
+	TableBorder: NewFormat().WithForeground(FgBrightBlack),
+}
+
+// Block level syntax recognized by the MarkdownRenderer.
+var (
+	mdHeadingRe    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdRuleRe       = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdFenceRe      = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+	mdListRe       = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])(?:[ \t]+|$)`)
+	mdQuoteRe      = regexp.MustCompile(`^ {0,3}> ?`)
+	mdTableDelimRe = regexp.MustCompile(`^ {0,3}\|? *:?-+:? *(?:\| *:?-+:? *)*\|? *$`)
+	mdAutolinkRe   = regexp.MustCompile(`^<((?:https?|ftp|mailto):[^<>\s]+)>`)
+)
+
+// mdEscapable lists the ASCII punctuation characters that can be escaped with a backslash.
+const mdEscapable = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// mdTabWidth is the number of spaces a tab is expanded to.
+const mdTabWidth = 4
+
+// MarkdownRenderer renders Markdown documents for terminals. It supports a subset of CommonMark with GitHub tables:
+// ATX headings, emphasis, strong emphasis, strike-through, code spans, fenced and indented code blocks, bullet and
+// ordered lists, block quotes, links, images (displayed as their text), tables and thematic breaks. Inline HTML is
+// displayed as is.
+//
+// Paragraphs, headings and list items are wrapped to the Width; code blocks are not wrapped.
+type MarkdownRenderer struct {
+	// Width is the maximum number of terminal cells per line, 80 if zero.
+	Width int
+	// Theme holds the Formats of the document elements. DefaultMarkdownTheme is used if nil.
+	Theme *MarkdownTheme
+}
+
+// RenderMarkdown renders a Markdown document wrapped to width terminal cells for the global Profile, using
+// DefaultMarkdownTheme. See MarkdownRenderer.
+func RenderMarkdown(src string, width int) string {
+	return MarkdownRenderer{Width: width}.Render(src, GetProfile())
+}
+
+// Render renders a Markdown document for a terminal with the provided Profile.
+func (r MarkdownRenderer) Render(src string, p Profile) string {
+	width := r.Width
+	if width <= 0 {
+		width = 80
+	}
+	theme := r.Theme
+	if theme == nil {
+		theme = &DefaultMarkdownTheme
+	}
+	md := &mdRenderer{theme: theme, profile: p}
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(src, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+	out := md.blocks(lines, width, false)
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// mdRenderer holds the settings of a rendering in progress.
+type mdRenderer struct {
+	theme   *MarkdownTheme
+	profile Profile
+}
+
+// blocks renders a sequence of lines holding block elements to output lines of at most width cells. Blocks are
+// separated by blank lines unless tight is set.
+func (md *mdRenderer) blocks(lines []string, width int, tight bool) []string {
+	width = max(width, 1)
+	var out []string
+	add := func(block []string) {
+		if len(out) > 0 && !tight {
+			out = append(out, "")
+		}
+		out = append(out, block...)
+	}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case mdFenceRe.MatchString(line):
+			var block []string
+			block, i = md.fencedCode(lines, i)
+			add(block)
+		case mdHeadingRe.MatchString(line):
+			m := mdHeadingRe.FindStringSubmatch(line)
+			f := md.format(md.theme.Headings[len(m[1])-1])
+			add(md.wrap(md.inline(m[2], f), width))
+			i++
+		case mdRuleRe.MatchString(line):
+			add([]string{md.styled(md.theme.Rule, strings.Repeat("─", width))})
+			i++
+		case mdQuoteRe.MatchString(line):
+			var inner []string
+			for ; i < len(lines) && mdQuoteRe.MatchString(lines[i]); i++ {
+				inner = append(inner, mdQuoteRe.ReplaceAllString(lines[i], ""))
+			}
+			bar := md.styled(md.theme.BlockQuote, "│") + " "
+			block := md.blocks(inner, width-2, false)
+			for j := range block {
+				block[j] = bar + block[j]
+			}
+			add(block)
+		case mdListRe.MatchString(line):
+			var block []string
+			block, i = md.list(lines, i, width)
+			add(block)
+		case strings.HasPrefix(line, "    "):
+			var code []string
+			for ; i < len(lines) && (strings.HasPrefix(lines[i], "    ") || strings.TrimSpace(lines[i]) == ""); i++ {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+			}
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			add(md.codeLines(code))
+		case i+1 < len(lines) && strings.Contains(line, "|") && mdTableDelimRe.MatchString(lines[i+1]):
+			var block []string
+			block, i = md.table(lines, i, width)
+			add(block)
+		default:
+			var para []string
+			for ; i < len(lines) && !md.interrupts(lines[i]); i++ {
+				para = append(para, lines[i])
+			}
+			add(md.wrap(md.inline(joinParagraph(para), NewFormat()), width))
+		}
+	}
+	return out
+}
+
+// interrupts reports whether a line ends the paragraph before it.
+func (md *mdRenderer) interrupts(line string) bool {
+	return strings.TrimSpace(line) == "" || mdFenceRe.MatchString(line) || mdHeadingRe.MatchString(line) ||
+		mdRuleRe.MatchString(line) || mdQuoteRe.MatchString(line) || mdListRe.MatchString(line)
+}
+
+// fencedCode renders the fenced code block starting at lines[i] and returns the index of the line after it.
+func (md *mdRenderer) fencedCode(lines []string, i int) ([]string, int) {
+	m := mdFenceRe.FindStringSubmatch(lines[i])
+	indent, fence := len(m[1]), m[2]
+	var code []string
+	for i++; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) && strings.Trim(lines[i], " "+fence[:1]) == "" {
+			i++
+			break
+		}
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+	return md.codeLines(code), i
+}
+
+// codeLines renders the lines of a code block, indented by two spaces.
+func (md *mdRenderer) codeLines(code []string) []string {
+	out := make([]string, len(code))
+	for i, line := range code {
+		out[i] = "  " + md.styled(md.theme.CodeBlock, line)
+	}
+	return out
+}
+
+// list renders the list starting at lines[i] and returns the index of the line after it. The items of a tight
+// list are not separated by blank lines, nor are the blocks within them.
+func (md *mdRenderer) list(lines []string, i, width int) ([]string, int) {
+	first := mdListRe.FindStringSubmatch(lines[i])
+	ordered := isListNumber(first[2])
+	number := 1
+	if ordered {
+		number, _ = strconv.Atoi(first[2][:len(first[2])-1])
+	}
+	var markers []string
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		m := mdListRe.FindStringSubmatch(lines[i])
+		if m == nil || isListNumber(m[2]) != ordered {
+			break
+		}
+		indent := len(m[0])
+		if strings.TrimSpace(lines[i]) == strings.TrimSpace(m[0]) {
+			indent = len(m[1]) + len(m[2]) + 1
+		}
+		item := []string{lines[i][min(len(m[0]), len(lines[i])):]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			switch {
+			case strings.TrimSpace(line) == "":
+				next := i + 1
+				for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+					next++
+				}
+				if next < len(lines) && leadingSpaces(lines[next]) >= indent {
+					item = append(item, "")
+					loose = true
+					continue
+				}
+			case leadingSpaces(line) >= indent:
+				item = append(item, line[indent:])
+				continue
+			case strings.TrimSpace(item[len(item)-1]) != "" && !md.interrupts(line):
+				// Lazy continuation of the item's paragraph.
+				item = append(item, strings.TrimSpace(line))
+				continue
+			}
+			break
+		}
+		// A blank line between two items makes the list loose.
+		next := i
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next > i && next < len(lines) {
+			if m := mdListRe.FindStringSubmatch(lines[next]); m != nil && isListNumber(m[2]) == ordered {
+				loose = true
+				i = next
+			}
+		}
+
+		marker := "•"
+		if ordered {
+			marker = strconv.Itoa(number) + m[2][len(m[2])-1:]
+			number++
+		}
+		markers = append(markers, marker)
+		items = append(items, item)
+	}
+
+	var out []string
+	for n, item := range items {
+		markerWidth := Width(markers[n]) + 1
+		body := md.blocks(item, width-markerWidth, !loose)
+		if len(body) == 0 {
+			body = []string{""}
+		}
+		if loose && n > 0 {
+			out = append(out, "")
+		}
+		for j, line := range body {
+			prefix := strings.Repeat(" ", markerWidth)
+			if j == 0 {
+				prefix = md.styled(md.theme.ListMarker, markers[n]) + " "
+			}
+			out = append(out, strings.TrimRight(prefix+line, " "))
+		}
+	}
+	return out, i
+}
+
+// isListNumber reports whether a list marker belongs to an ordered list.
+func isListNumber(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// table renders the table starting at lines[i] and returns the index of the line after it.
+func (md *mdRenderer) table(lines []string, i, width int) ([]string, int) {
+	header := splitTableRow(lines[i])
+	var aligns []byte
+	for _, cell := range splitTableRow(lines[i+1]) {
+		cell = strings.TrimSpace(cell)
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, 'c')
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, 'r')
+		default:
+			aligns = append(aligns, 'l')
+		}
+	}
+	rows := [][]string{header}
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		rows = append(rows, splitTableRow(lines[i]))
+	}
+
+	cols := len(aligns)
+	cells := make([][]string, len(rows))
+	widths := make([]int, cols)
+	for r, row := range rows {
+		base := NewFormat()
+		if r == 0 {
+			base = md.format(md.theme.TableHeader)
+		}
+		cells[r] = make([]string, cols)
+		for c := 0; c < cols; c++ {
+			if c < len(row) {
+				cells[r][c] = md.inline(strings.TrimSpace(row[c]), base).Render(md.profile)
+			}
+			widths[c] = max(widths[c], Width(cells[r][c]))
+		}
+	}
+	// Shrink the widest columns until the table fits.
+	for {
+		total, widest := 3*(cols-1), 0
+		for c, w := range widths {
+			total += w
+			if w > widths[widest] {
+				widest = c
+			}
+		}
+		if total <= width || widths[widest] <= 1 {
+			break
+		}
+		widths[widest]--
+	}
+
+	sep := md.styled(md.theme.TableBorder, " │ ")
+	var out []string
+	for r, row := range cells {
+		parts := make([]string, cols)
+		for c, cell := range row {
+			cell = Truncate(cell, widths[c], Ellipsis)
+			switch aligns[c] {
+			case 'r':
+				parts[c] = PadLeft(cell, widths[c], nil)
+			case 'c':
+				parts[c] = Center(cell, widths[c], nil)
+			default:
+				parts[c] = PadRight(cell, widths[c], nil)
+			}
+		}
+		out = append(out, strings.TrimRight(strings.Join(parts, sep), " "))
+		if r == 0 {
+			rules := make([]string, cols)
+			for c, w := range widths {
+				rules[c] = strings.Repeat("─", w)
+			}
+			out = append(out, md.styled(md.theme.TableBorder, strings.Join(rules, "─┼─")))
+		}
+	}
+	return out, i
+}
+
+// splitTableRow splits a table row into cells at unescaped pipes, dropping the optional outer pipes.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, cell.String())
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, cell.String())
+}
+
+// wrap renders inline text and wraps it to width cells.
+func (md *mdRenderer) wrap(t *StyledText, width int) []string {
+	return strings.Split(WordWrap(t.Render(md.profile), width), "\n")
+}
+
+// styled returns s displayed with a theme Format.
+func (md *mdRenderer) styled(f *Format, s string) string {
+	return renderStyled(md.format(f), s, md.profile)
+}
+
+// format returns a theme Format, or a Format without attributes if it is nil.
+func (md *mdRenderer) format(f *Format) *Format {
+	if f == nil {
+		return NewFormat()
+	}
+	return f
+}
+
+// inline parses the inline elements of s into a StyledText based on the provided Format.
+func (md *mdRenderer) inline(s string, base *Format) *StyledText {
+	t := NewStyledText()
+	md.inlineInto(t, s, base)
+	return t
+}
+
+// inlineInto parses the inline elements of s, appending them to t.
+func (md *mdRenderer) inlineInto(t *StyledText, s string, f *Format) {
+	var text strings.Builder
+	flush := func() {
+		t.appendSpan(Span{Text: text.String(), Format: f})
+		text.Reset()
+	}
+	ends := map[int]int{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			text.WriteByte('\n')
+			i += 2
+			continue
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(mdEscapable, s[i+1]) >= 0:
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+		case c == '`':
+			n := runLength(s, i)
+			if end := strings.Index(s[i+n:], s[i:i+n]); end >= 0 && runLength(s, i+n+end) == n {
+				code := strings.ReplaceAll(s[i+n:i+n+end], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				flush()
+				t.appendSpan(Span{Text: code, Format: f.overlay(md.format(md.theme.Code))})
+				i += 2*n + end
+				continue
+			}
+			text.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '*' || c == '_' || c == '~':
+			if delim, style, end := md.emphasis(s, i, ends); end >= 0 {
+				flush()
+				md.inlineInto(t, s[i+len(delim):end], f.overlay(md.format(style)))
+				i = end + len(delim)
+				continue
+			}
+			n := runLength(s, i)
+			text.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '[' || c == '!' && i+1 < len(s) && s[i+1] == '[':
+			start := i
+			if c == '!' {
+				start++
+			}
+			if label, dest, end := parseLink(s, start); end >= 0 {
+				flush()
+				md.inlineInto(t, label, f.overlay(md.format(md.theme.Link)))
+				if dest != "" && dest != label {
+					t.appendSpan(Span{Text: " (" + dest + ")", Format: f.overlay(md.format(md.theme.LinkURL))})
+				}
+				i = end
+				continue
+			}
+		case c == '<':
+			if m := mdAutolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				flush()
+				t.appendSpan(Span{Text: m[1], Format: f.overlay(md.format(md.theme.Link))})
+				i += len(m[0])
+				continue
+			}
+		}
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+}
+
+// emphasis looks for emphasis opened by the delimiter run at s[i]. It returns the delimiter, the theme Format of
+// the emphasis and the index of the closing delimiter, or -1 if the run does not open emphasis. ends caches the
+// closing index found for each opening run of s, so that runs skipped while looking for a closer are only
+// examined once and the search stays polynomial.
+func (md *mdRenderer) emphasis(s string, i int, ends map[int]int) (string, *Format, int) {
+	c, n := s[i], runLength(s, i)
+	var delim string
+	var style *Format
+	switch {
+	case c == '~' && n == 2:
+		delim, style = "~~", md.theme.Strike
+	case c == '~':
+		return "", nil, -1
+	case n >= 2:
+		delim, style = s[i:i+2], md.theme.Strong
+	default:
+		delim, style = s[i:i+1], md.theme.Emphasis
+	}
+	end, ok := ends[i]
+	if !ok {
+		end = md.closeEmphasis(s, i, delim, ends)
+		ends[i] = end
+	}
+	if end < 0 {
+		return "", nil, -1
+	}
+	return delim, style, end
+}
+
+// closeEmphasis returns the index of the delimiter run closing the emphasis opened by delim at s[i], or -1 if
+// there is none. See emphasis.
+func (md *mdRenderer) closeEmphasis(s string, i int, delim string, ends map[int]int) int {
+	c := s[i]
+	after := i + len(delim)
+	if after >= len(s) || s[after] == ' ' || c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return -1
+	}
+	for j := after; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			if end := strings.Index(s[j+1:], "`"); end >= 0 {
+				j += end + 1
+			}
+		case c:
+			// A run closes the emphasis with its last delimiters. Runs of another length belong to nested
+			// emphasis, e.g. the strong emphasis in "*a **b** c*".
+			run := runLength(s, j)
+			if s[j-1] == ' ' {
+				// The run can only open emphasis: skip the emphasis it opens.
+				if d, _, end := md.emphasis(s, j, ends); end >= 0 {
+					j = end + len(d) - 1
+					continue
+				}
+			}
+			closes := run == len(delim) || run > 2 && c != '~'
+			if j > after && s[j-1] != ' ' && closes && !(c == '_' && j+run < len(s) && isWordByte(s[j+run])) {
+				return j + run - len(delim)
+			}
+			j += run - 1
+		}
+	}
+	return -1
+}
+
+// parseLink parses a link starting at the opening bracket s[i]: [label](destination "title"). It returns the
+// label, the destination and the index after the link, or -1 if there is no link at s[i].
+func parseLink(s string, i int) (string, string, int) {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if j+1 >= len(s) || s[j+1] != '(' {
+				return "", "", -1
+			}
+			end := strings.IndexByte(s[j+2:], ')')
+			if end < 0 {
+				return "", "", -1
+			}
+			dest := strings.TrimSpace(s[j+2 : j+2+end])
+			if k := strings.IndexAny(dest, " \t"); k >= 0 {
+				dest = dest[:k] // drop the title
+			}
+			dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+			return s[i+1 : j], dest, j + 3 + end
+		}
+	}
+	return "", "", -1
+}
+
+// joinParagraph joins the lines of a paragraph. Line breaks become spaces, except hard line breaks marked by two
+// trailing spaces or a backslash.
+func joinParagraph(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		last := i == len(lines)-1
+		switch {
+		case !last && strings.HasSuffix(line, "  "):
+			b.WriteString(strings.TrimRight(line, " "))
+			b.WriteString("\\\n")
+		case !last && strings.HasSuffix(line, "\\"):
+			b.WriteString(line)
+			b.WriteString("\n")
+		case !last:
+			b.WriteString(strings.TrimRight(line, " "))
+			b.WriteString(" ")
+		default:
+			b.WriteString(strings.TrimRight(line, " \\"))
+		}
+	}
+	return b.String()
+}
+
+// runLength returns the number of consecutive occurrences of the byte s[i] starting at i.
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// leadingSpaces returns the number of spaces at the start of s.
+func leadingSpaces(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " "))
+}
+
+// isWordByte reports whether b is an ASCII letter or digit.
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// expandTabs replaces the tabs of a line with spaces up to the next tab stop.
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := mdTabWidth - col%mdTabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package ansicolor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownEmphasis(t *testing.T) {
+	italic := NewFormat().WithOption(SGROptItalic)
+	bold := NewFormat().WithOption(SGROptBold)
+	r := MarkdownRenderer{Width: 200}
+	tests := []struct {
+		in   string
+		want []Span
+	}{
+		{in: "a *b* c", want: []Span{{Text: "a ", Format: NewFormat()}, {Text: "b", Format: italic},
+			{Text: " c", Format: NewFormat()}}},
+		{in: "**b** _i_", want: []Span{{Text: "b", Format: bold}, {Text: " ", Format: NewFormat()},
+			{Text: "i", Format: italic}}},
+		{in: "*a **b** c*", want: []Span{{Text: "a ", Format: italic},
+			{Text: "b", Format: italic.WithOption(SGROptBold)}, {Text: " c", Format: italic}}},
+		{in: "~~gone~~", want: []Span{{Text: "gone", Format: NewFormat().WithOption(SGROptStrike)}}},
+		{in: "*a *b* c*", want: []Span{{Text: "a b c", Format: italic}}},
+		{in: "2 * 3 * 4", want: []Span{{Text: "2 * 3 * 4", Format: NewFormat()}}},
+		{in: "snake_case_name", want: []Span{{Text: "snake_case_name", Format: NewFormat()}}},
+		{in: `\*not\* *a `, want: []Span{{Text: "*not* *a", Format: NewFormat()}}},
+		{in: "`*code*`", want: []Span{{Text: "*code*", Format: NewFormat().WithForeground(FgYellow)}}},
+	}
+	for _, tt := range tests {
+		got := Decode(strings.TrimSuffix(r.Render(tt.in, ProfileTrueColor), "\n"))
+		if !equalSpans(got, tt.want) {
+			t.Errorf("Render(%q) = %s, want %s", tt.in, spansString(got.Spans()), spansString(tt.want))
+		}
+	}
+}
+
+// TestMarkdownEmphasisTime checks that unmatched delimiter runs, each of which only opens emphasis, are rendered
+// in polynomial time.
+func TestMarkdownEmphasisTime(t *testing.T) {
+	for _, src := range []string{
+		strings.Repeat("*a ", 28),
+		strings.Repeat("*a ", 2000),
+		strings.Repeat("_a **b ", 1000),
+		strings.Repeat("~~a ", 2000),
+	} {
+		done := make(chan string, 1)
+		go func() {
+			done <- MarkdownRenderer{Width: 1 << 20}.Render(src, ProfilePlain)
+		}()
+		select {
+		case got := <-done:
+			if want := strings.TrimSpace(src) + "\n"; got != want {
+				t.Errorf("Render(%.20q...) = %.20q..., want the text unchanged", src, got)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Render(%.20q...) did not finish in time", src)
+		}
+	}
+}
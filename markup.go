@@ -0,0 +1,162 @@
+package ansicolor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrMarkupUnterminatedTag indicates a markup tag without its closing bracket.
+// ErrMarkupUnmatchedClose indicates a closing markup tag that does not match the innermost open tag.
+var (
+	ErrMarkupUnterminatedTag = errors.New("unterminated markup tag")
+	ErrMarkupUnmatchedClose  = errors.New("closing markup tag does not match an open tag")
+)
+
+// MarkupError reports an error in markup text along with its position. Line and Column start at 1; Column counts
+// characters.
+type MarkupError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *MarkupError) Error() string {
+	return fmt.Sprintf("markup: line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *MarkupError) Unwrap() error {
+	return e.Err
+}
+
+// markupTag is an open markup tag.
+type markupTag struct {
+	spec   string
+	format *Format
+}
+
+// ParseMarkup parses text with inline style tags into a StyledText:
+//
+//	[bold red]error:[/] cannot open [underline]config.yaml[/]
+//
+// An opening tag holds a style specification as accepted by ParseStyle, such as [bold], [bright red on blue] or
+// [italic #ff8800]. Tags nest: the style of an inner tag is applied on top of the outer ones. [/] closes the
+// innermost open tag, and so does [/spec] after checking that spec matches it. Tags still open at the end of the
+// text are closed implicitly.
+//
+// A bracket starts a tag only when the first word after it, or after the '/' of a closing tag, is a style name or
+// an RGB color, so text such as "array[0]", "list[i]" or "map[key]" needs no escaping. Otherwise a backslash escapes
+// a bracket or another backslash: "\[bold]" is displayed as "[bold]". EscapeMarkup escapes arbitrary text for
+// inclusion in markup.
+//
+// Errors are returned as a *MarkupError holding the position of the offending tag.
+func ParseMarkup(s string) (*StyledText, error) {
+	t := NewStyledText()
+	stack := []markupTag{{format: NewFormat()}}
+	var text strings.Builder
+	flush := func() {
+		t.appendSpan(Span{Text: text.String(), Format: stack[len(stack)-1].format})
+		text.Reset()
+	}
+
+	line, col := 1, 1
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '[' || s[i+1] == '\\'):
+			text.WriteByte(s[i+1])
+			i += 2
+			col += 2
+			continue
+		case c == '[' && isMarkupTag(s[i+1:]):
+			end := strings.IndexAny(s[i+1:], "]\n")
+			if end < 0 || s[i+1+end] == '\n' {
+				return nil, &MarkupError{Line: line, Column: col, Err: ErrMarkupUnterminatedTag}
+			}
+			content := s[i+1 : i+1+end]
+			flush()
+			if strings.HasPrefix(content, "/") {
+				spec := strings.TrimSpace(content[1:])
+				top := stack[len(stack)-1]
+				if len(stack) == 1 || spec != "" && !sameStyleSpec(spec, top.spec) {
+					err := fmt.Errorf("%w: [%s]", ErrMarkupUnmatchedClose, content)
+					return nil, &MarkupError{Line: line, Column: col, Err: err}
+				}
+				stack = stack[:len(stack)-1]
+			} else {
+				f, err := ParseStyle(content)
+				if err != nil {
+					return nil, &MarkupError{Line: line, Column: col, Err: err}
+				}
+				stack = append(stack, markupTag{spec: content, format: stack[len(stack)-1].format.overlay(f)})
+			}
+			i += end + 2
+			col += utf8.RuneCountInString(content) + 2
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		text.WriteString(s[i : i+size])
+		i += size
+		col++
+		if c == '\n' {
+			line++
+			col = 1
+		}
+	}
+	flush()
+	return t, nil
+}
+
+// Markup parses markup text with ParseMarkup and renders it for the global Profile.
+func Markup(s string) (string, error) {
+	t, err := ParseMarkup(s)
+	if err != nil {
+		return "", err
+	}
+	return t.Render(GetProfile()), nil
+}
+
+// EscapeMarkup escapes the brackets and backslashes of s so that it is displayed literally when included in
+// markup text, e.g. for file names or user input.
+func EscapeMarkup(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`).Replace(s)
+}
+
+// isMarkupTag reports whether the text following an opening bracket starts a tag: a closing tag or a style
+// specification starting with a known word. Other brackets are kept as text.
+func isMarkupTag(s string) bool {
+	if end := strings.IndexAny(s, "]\n"); end >= 0 {
+		s = s[:end]
+	}
+	if strings.HasPrefix(s, "/") {
+		s = strings.TrimSpace(s[1:])
+		if s == "" {
+			return true
+		}
+	}
+	if s == "" {
+		return false
+	}
+	if c := s[0]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '#') {
+		return false
+	}
+	words := strings.Fields(strings.ToLower(s))
+	if words[0] == "on" || words[0] == "ul" {
+		return true
+	}
+	next := ""
+	if len(words) > 1 {
+		next = words[1]
+	}
+	_, _, ok := applyStyleWord(NewFormat(), words[0], next, false)
+	return ok
+}
+
+// sameStyleSpec reports whether two style specifications are written the same, ignoring case and spacing.
+func sameStyleSpec(a, b string) bool {
+	normalize := func(spec string) string {
+		return strings.Join(strings.Fields(strings.ToLower(spec)), " ")
+	}
+	return normalize(a) == normalize(b)
+}
@@ -0,0 +1,82 @@
+package ansicolor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	bold := NewFormat().WithOption(SGROptBold)
+	boldRed := bold.WithForeground(FgRed)
+	tests := []struct {
+		in   string
+		want []Span
+	}{
+		{in: "plain", want: []Span{{Text: "plain", Format: NewFormat()}}},
+		{in: "[bold red]error:[/] cannot open", want: []Span{
+			{Text: "error:", Format: boldRed},
+			{Text: " cannot open", Format: NewFormat()},
+		}},
+		{in: "[bold]a[red]b[/red]c[/bold]d", want: []Span{
+			{Text: "a", Format: bold},
+			{Text: "b", Format: boldRed},
+			{Text: "c", Format: bold},
+			{Text: "d", Format: NewFormat()},
+		}},
+		{in: "[red]a[blue]b", want: []Span{
+			{Text: "a", Format: NewFormat().WithForeground(FgRed)},
+			{Text: "b", Format: NewFormat().WithForeground(FgBlue)},
+		}},
+		{in: "[#ff8800 on blue]x", want: []Span{
+			{Text: "x", Format: NewFormat().WithForegroundColor(TrueColor(0xff, 0x88, 0)).WithBackground(BgBlue)},
+		}},
+		{in: "[Bold  Red]x[/bold red]", want: []Span{{Text: "x", Format: boldRed}}},
+		{in: "array[0] = x[1]", want: []Span{{Text: "array[0] = x[1]", Format: NewFormat()}}},
+		{in: "list[i] = map[key]", want: []Span{{Text: "list[i] = map[key]", Format: NewFormat()}}},
+		{in: "[bold]m[key][/] [bolld] x[#12] dir[/usr]", want: []Span{
+			{Text: "m[key]", Format: bold},
+			{Text: " [bolld] x[#12] dir[/usr]", Format: NewFormat()},
+		}},
+		{in: "[bright red]x[/ bright red] [on blue]y", want: []Span{
+			{Text: "x", Format: NewFormat().WithForeground(FgBrightRed)},
+			{Text: " ", Format: NewFormat()},
+			{Text: "y", Format: NewFormat().WithBackground(BgBlue)},
+		}},
+		{in: `\[bold] \\ \x`, want: []Span{{Text: `[bold] \ \x`, Format: NewFormat()}}},
+		{in: EscapeMarkup(`[red]\`), want: []Span{{Text: `[red]\`, Format: NewFormat()}}},
+		{in: "[bold]a\nb", want: []Span{{Text: "a\nb", Format: bold}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseMarkup(tt.in)
+		if err != nil {
+			t.Errorf("ParseMarkup(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !equalSpans(got, tt.want) {
+			t.Errorf("ParseMarkup(%q) = %s, want %s", tt.in, spansString(got.Spans()), spansString(tt.want))
+		}
+	}
+}
+
+func TestParseMarkupErrors(t *testing.T) {
+	tests := []struct {
+		in           string
+		err          error
+		line, column int
+	}{
+		{in: "[bold", err: ErrMarkupUnterminatedTag, line: 1, column: 1},
+		{in: "ok\n  [red\n]", err: ErrMarkupUnterminatedTag, line: 2, column: 3},
+		{in: "text[/]", err: ErrMarkupUnmatchedClose, line: 1, column: 5},
+		{in: "[bold]é[/red]", err: ErrMarkupUnmatchedClose, line: 1, column: 8},
+		{in: "a\nb [bold blinking]", err: ErrInvalidStyle, line: 2, column: 3},
+		{in: "[red]x[/bold]", err: ErrMarkupUnmatchedClose, line: 1, column: 7},
+	}
+	for _, tt := range tests {
+		_, err := ParseMarkup(tt.in)
+		var merr *MarkupError
+		if !errors.As(err, &merr) || !errors.Is(err, tt.err) || merr.Line != tt.line || merr.Column != tt.column {
+			t.Errorf("ParseMarkup(%q) error = %v, want %v at line %d, column %d", tt.in, err, tt.err, tt.line,
+				tt.column)
+		}
+	}
+}
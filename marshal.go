@@ -0,0 +1,228 @@
+package ansicolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// formatJSON is the JSON object form of a Format.
+type formatJSON struct {
+	Fg             string   `json:"fg,omitempty"`
+	Bg             string   `json:"bg,omitempty"`
+	UnderlineColor string   `json:"underline_color,omitempty"`
+	Options        []string `json:"options,omitempty"`
+}
+
+// ThemeError reports an invalid entry of a theme file.
+type ThemeError struct {
+	Key string
+	Err error
+}
+
+func (e *ThemeError) Error() string {
+	return fmt.Sprintf("theme: %q: %v", e.Key, e.Err)
+}
+
+func (e *ThemeError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalText implements encoding.TextMarshaler. The Format is written as a style specification accepted by
+// ParseStyle, such as "bold underline red on bright white": option names, the foreground color, "on" and the
+// background color, then "ul" and the underline color. Palette colors are written as indexes and RGB colors as
+// #rrggbb. A Format without attributes is written as an empty string.
+func (f *Format) MarshalText() ([]byte, error) {
+	words := f.optionNames()
+	if fg := f.fgName(); fg != "" {
+		words = append(words, fg)
+	}
+	if bg := f.bgName(); bg != "" {
+		words = append(words, "on", bg)
+	}
+	if f.ulx != nil {
+		words = append(words, "ul", f.ulx.String())
+	}
+	return []byte(strings.Join(words, " ")), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing a style specification with ParseStyle.
+func (f *Format) UnmarshalText(text []byte) error {
+	nf, err := ParseStyle(string(text))
+	if err != nil {
+		return err
+	}
+	*f = *nf
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The Format is written as an object with the optional members "fg",
+// "bg" and "underline_color", holding color names as accepted by ParseStyle, and "options", holding a list of
+// option names:
+//
+//	{"fg": "red", "bg": "bright white", "underline_color": "#ff8800", "options": ["bold", "underline"]}
+func (f *Format) MarshalJSON() ([]byte, error) {
+	obj := formatJSON{Fg: f.fgName(), Bg: f.bgName(), Options: f.optionNames()}
+	if f.ulx != nil {
+		obj.UnderlineColor = f.ulx.String()
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both the object form written by MarshalJSON and a string holding a
+// style specification are accepted. Unknown members, colors and options are reported as errors.
+func (f *Format) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var spec string
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return err
+		}
+		return f.UnmarshalText([]byte(spec))
+	}
+	var obj formatJSON
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&obj); err != nil {
+		return err
+	}
+	nf := NewFormat()
+	for _, name := range obj.Options {
+		sgr, ok := SGRSetterLookup[strings.ReplaceAll(strings.ToLower(name), "_", " ")]
+		if !ok {
+			return fmt.Errorf("%w: unknown option %q in \"options\"", ErrInvalidStyle, name)
+		}
+		nf = nf.WithOption(sgrSetterOptions[int(sgr)])
+	}
+	if obj.Fg != "" {
+		if _, err := parseJSONColor("fg", obj.Fg); err != nil {
+			return err
+		}
+		nf, _ = applyStyleName(nf, strings.ToLower(obj.Fg), false)
+	}
+	if obj.Bg != "" {
+		if _, err := parseJSONColor("bg", obj.Bg); err != nil {
+			return err
+		}
+		nf, _ = applyStyleName(nf, strings.ToLower(obj.Bg), true)
+	}
+	if obj.UnderlineColor != "" {
+		c, err := parseJSONColor("underline_color", obj.UnderlineColor)
+		if err != nil {
+			return err
+		}
+		nf = nf.withUnderline(backgroundAsColor(c))
+	}
+	*f = *nf
+	return nil
+}
+
+// parseJSONColor parses the color name of a member of the JSON object form of a Format into the background color
+// of a new Format. Colors are parsed as background colors, which excludes option names.
+func parseJSONColor(member, name string) (*Format, error) {
+	c, ok := applyStyleName(NewFormat(), strings.ToLower(name), true)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown color %q in %q", ErrInvalidStyle, name, member)
+	}
+	return c, nil
+}
+
+// ParseTheme parses a JSON theme: an object mapping style names to Formats, each in the object or string form
+// accepted by Format.UnmarshalJSON.
+//
+//	{
+//	  "error":       "bold red",
+//	  "error.title": {"fg": "bright white", "bg": "red"},
+//	  "muted":       {"fg": "244", "options": ["italic"]}
+//	}
+//
+// The result can be installed with StyleRegistry.Replace. An invalid entry is reported as a *ThemeError naming
+// its key; if several entries are invalid, the first one in sorted key order is reported.
+func ParseTheme(data []byte) (map[string]*Format, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("theme: %w", err)
+	}
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	theme := make(map[string]*Format, len(raw))
+	for _, key := range keys {
+		f := NewFormat()
+		if err := f.UnmarshalJSON(raw[key]); err != nil {
+			return nil, &ThemeError{Key: key, Err: err}
+		}
+		theme[key] = f
+	}
+	return theme, nil
+}
+
+// LoadTheme reads a JSON theme, such as a theme file, and parses it with ParseTheme.
+func LoadTheme(r io.Reader) (map[string]*Format, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTheme(data)
+}
+
+// optionNames returns the names of the options of the Format, in the order of sgrOptOrder.
+func (f *Format) optionNames() []string {
+	var names []string
+	for _, opt := range sgrOptOrder {
+		if !f.opts.Has(opt) {
+			continue
+		}
+		for name, sgr := range SGRSetterLookup {
+			if sgr == SGROptSetterLookup[opt] {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// fgName returns the name of the foreground color of the Format, or an empty string if none is set.
+func (f *Format) fgName() string {
+	switch {
+	case f.fgx != nil:
+		return f.fgx.String()
+	case f.fg == nil:
+		return ""
+	case *f.fg == FgDefault:
+		return "default"
+	}
+	for name, c := range FgColorLookup {
+		if c == *f.fg {
+			return name
+		}
+	}
+	return ""
+}
+
+// bgName returns the name of the background color of the Format, or an empty string if none is set.
+func (f *Format) bgName() string {
+	switch {
+	case f.bgx != nil:
+		return f.bgx.String()
+	case f.bg == nil:
+		return ""
+	case *f.bg == BgDefault:
+		return "default"
+	}
+	for name, c := range BgColorLookup {
+		if c == *f.bg {
+			return strings.ReplaceAll(name, "_", " ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package ansicolor
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// marshalFormats are Formats covering every kind of attribute, used for round-trip tests.
+var marshalFormats = []*Format{
+	NewFormat(),
+	NewFormat().WithOption(SGROptBold | SGROptUnderline),
+	NewFormat().WithForeground(FgRed),
+	NewFormat().WithForeground(FgBrightBlue).WithBackground(BgBrightWhite),
+	NewFormat().WithForeground(FgDefault).WithBackground(BgDefault),
+	NewFormat().WithForegroundColor(Color256(208)).WithBackgroundColor(TrueColor(0x1e, 0x1e, 0x2e)),
+	NewFormat().WithOption(SGROptDoubleUnderline | SGROptItalic).WithUnderlineColor(TrueColor(0xff, 0x88, 0)),
+	NewFormat().WithOption(SGROptStrike | SGROptFaint | SGROptReverse | SGROptConceal | SGROptFastBlink),
+}
+
+func TestFormatTextRoundTrip(t *testing.T) {
+	for _, f := range marshalFormats {
+		text, err := f.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := NewFormat()
+		if err := got.UnmarshalText(text); err != nil {
+			t.Errorf("UnmarshalText(%q) error = %v", text, err)
+			continue
+		}
+		if !got.Equal(f) {
+			again, _ := got.MarshalText()
+			t.Errorf("UnmarshalText(%q) = %q", text, again)
+		}
+	}
+}
+
+func TestFormatJSONRoundTrip(t *testing.T) {
+	for _, f := range marshalFormats {
+		data, err := json.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := NewFormat()
+		if err := json.Unmarshal(data, got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", data, err)
+			continue
+		}
+		if !got.Equal(f) {
+			again, _ := json.Marshal(got)
+			t.Errorf("Unmarshal(%s) = %s", data, again)
+		}
+	}
+}
+
+func TestFormatMarshal(t *testing.T) {
+	f := NewFormat().WithForeground(FgRed).WithBackground(BgBrightWhite).WithOption(SGROptBold | SGROptUnderline).
+		WithUnderlineColor(TrueColor(0xff, 0x88, 0))
+	text, _ := f.MarshalText()
+	if want := "bold underline red on bright white ul #ff8800"; string(text) != want {
+		t.Errorf("MarshalText() = %q, want %q", text, want)
+	}
+	data, _ := json.Marshal(f)
+	if want := `{"fg":"red","bg":"bright white","underline_color":"#ff8800","options":["bold","underline"]}`; string(data) != want {
+		t.Errorf("MarshalJSON() = %s, want %s", data, want)
+	}
+	if text, _ := NewFormat().MarshalText(); len(text) != 0 {
+		t.Errorf("empty Format: MarshalText() = %q, want \"\"", text)
+	}
+}
+
+func TestFormatUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *Format
+		err  bool
+	}{
+		{in: `"bold red on blue"`, want: NewFormat().WithOption(SGROptBold).WithForeground(FgRed).WithBackground(BgBlue)},
+		{in: `{"fg": "#ff8800", "options": ["Italic", "double_underline"]}`,
+			want: NewFormat().WithForegroundColor(TrueColor(0xff, 0x88, 0)).
+				WithOption(SGROptItalic | SGROptDoubleUnderline)},
+		{in: `{"bg": "bright_black"}`, want: NewFormat().WithBackground(BgBrightBlack)},
+		{in: `{}`, want: NewFormat()},
+		{in: `{"fg": "bold"}`, err: true},
+		{in: `{"fg": "red", "color": "blue"}`, err: true},
+		{in: `{"options": ["sparkle"]}`, err: true},
+		{in: `{"underline_color": "nope"}`, err: true},
+		{in: `"red on"`, err: true},
+		{in: `42`, err: true},
+	}
+	for _, tt := range tests {
+		got := NewFormat()
+		err := json.Unmarshal([]byte(tt.in), got)
+		switch {
+		case tt.err:
+			if err == nil {
+				t.Errorf("Unmarshal(%s) succeeded, want an error", tt.in)
+			}
+		case err != nil:
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+		case !got.Equal(tt.want):
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	// null leaves the Format unchanged.
+	f := NewFormat().WithForeground(FgRed)
+	if err := json.Unmarshal([]byte("null"), f); err != nil || !f.Equal(NewFormat().WithForeground(FgRed)) {
+		t.Errorf("Unmarshal(null) = %v, %v", f, err)
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	theme, err := LoadTheme(strings.NewReader(`{
+		"error":       "bold red",
+		"error.title": {"fg": "bright white", "bg": "red"},
+		"muted":       {"fg": "244", "options": ["italic"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*Format{
+		"error":       NewFormat().WithOption(SGROptBold).WithForeground(FgRed),
+		"error.title": NewFormat().WithForeground(FgBrightWhite).WithBackground(BgRed),
+		"muted":       NewFormat().WithForegroundColor(Color256(244)).WithOption(SGROptItalic),
+	}
+	if len(theme) != len(want) {
+		t.Errorf("ParseTheme() has %d styles, want %d", len(theme), len(want))
+	}
+	for name, f := range want {
+		if !theme[name].Equal(f) {
+			t.Errorf("ParseTheme()[%q] = %v, want %v", name, theme[name], f)
+		}
+	}
+
+	_, err = ParseTheme([]byte(`{"b": "bold", "a": "sparkle", "c": {"fg": "nope"}}`))
+	var themeErr *ThemeError
+	if !errors.As(err, &themeErr) || themeErr.Key != "a" || !errors.Is(err, ErrInvalidStyle) {
+		t.Errorf("ParseTheme() error = %v, want a ThemeError for \"a\"", err)
+	}
+	if _, err := ParseTheme([]byte(`[]`)); err == nil {
+		t.Error("ParseTheme([]) succeeded, want an error")
+	}
+}
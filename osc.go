@@ -0,0 +1,135 @@
+package ansicolor
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartOSC defines the starting sequence for an Operating System Command.
+// EndOSC defines the string terminator (ST) that ends an Operating System Command.
+const (
+	StartOSC = "\033]"
+	EndOSC   = "\033\\"
+)
+
+// oscForeground is the OSC code addressing the default foreground color.
+// oscBackground is the OSC code addressing the default background color.
+const (
+	oscForeground = 10
+	oscBackground = 11
+)
+
+// ColorScheme classifies a terminal's background as light or dark.
+type ColorScheme int
+
+// SchemeUnknown means the terminal background could not be determined.
+// SchemeDark means the terminal has a dark background.
+// SchemeLight means the terminal has a light background.
+const (
+	SchemeUnknown ColorScheme = iota
+	SchemeDark
+	SchemeLight
+)
+
+// String returns the name of the ColorScheme.
+func (s ColorScheme) String() string {
+	switch s {
+	case SchemeDark:
+		return "dark"
+	case SchemeLight:
+		return "light"
+	}
+	return "unknown"
+}
+
+// TerminalColors holds the default colors reported by a terminal.
+// HasForeground is false when the terminal answered the background query but not the foreground one.
+type TerminalColors struct {
+	Foreground    RGB
+	Background    RGB
+	HasForeground bool
+	Scheme        ColorScheme
+}
+
+// QueryForegroundColor asks the terminal for its default foreground color using OSC 10.
+// The terminal must be in raw mode; see DetectColorScheme.
+func QueryForegroundColor(tty io.ReadWriter, timeout time.Duration) (RGB, error) {
+	return queryOSCColor(tty, timeout, strconv.Itoa(oscForeground))
+}
+
+// QueryBackgroundColor asks the terminal for its default background color using OSC 11.
+// The terminal must be in raw mode; see DetectColorScheme.
+func QueryBackgroundColor(tty io.ReadWriter, timeout time.Duration) (RGB, error) {
+	return queryOSCColor(tty, timeout, strconv.Itoa(oscBackground))
+}
+
+// DetectColorScheme queries the terminal's default foreground and background colors with OSC 10 and OSC 11 and
+// classifies the background as light or dark.
+//
+// tty is usually the controlling terminal (/dev/tty) put into raw mode by the caller, but any io.ReadWriter that
+// answers like a terminal will do. Returns ErrQueryTimeout if no reply arrives in time and ErrQueryUnsupported
+// if the terminal does not report its background color.
+func DetectColorScheme(tty io.ReadWriter, timeout time.Duration) (TerminalColors, error) {
+	fg, bg := strconv.Itoa(oscForeground), strconv.Itoa(oscBackground)
+	colors, err := queryOSCColors(tty, timeout, fg, bg)
+	if err != nil {
+		return TerminalColors{}, err
+	}
+	background, ok := colors[bg]
+	if !ok {
+		return TerminalColors{}, ErrQueryUnsupported
+	}
+	tc := TerminalColors{Background: background, Scheme: SchemeLight}
+	if background.IsDark() {
+		tc.Scheme = SchemeDark
+	}
+	tc.Foreground, tc.HasForeground = colors[fg]
+	return tc, nil
+}
+
+// queryOSCColor queries a single OSC color slot, such as "11" or "4;1".
+func queryOSCColor(tty io.ReadWriter, timeout time.Duration, slot string) (RGB, error) {
+	colors, err := queryOSCColors(tty, timeout, slot)
+	if err != nil {
+		return RGB{}, err
+	}
+	c, ok := colors[slot]
+	if !ok {
+		return RGB{}, ErrQueryUnsupported
+	}
+	return c, nil
+}
+
+// queryOSCColors queries several OSC color slots in one round trip and returns the colors that were reported,
+// keyed by slot.
+func queryOSCColors(tty io.ReadWriter, timeout time.Duration, slots ...string) (map[string]RGB, error) {
+	var b strings.Builder
+	for _, slot := range slots {
+		b.WriteString(StartOSC)
+		b.WriteString(slot)
+		b.WriteString(";?")
+		b.WriteString(EndOSC)
+	}
+	replies, err := queryTerminal(tty, b.String(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	colors := make(map[string]RGB, len(slots))
+	for _, reply := range replies {
+		if reply.Kind != TokenOSC {
+			continue
+		}
+		i := strings.LastIndexByte(reply.Data, ';')
+		if i < 0 {
+			continue
+		}
+		c, err := ParseXColor(reply.Data[i+1:])
+		if err != nil {
+			continue
+		}
+		colors[reply.Data[:i]] = c
+	}
+	return colors, nil
+}
@@ -0,0 +1,122 @@
+package ansicolor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseXColor(t *testing.T) {
+	tests := []struct {
+		spec string
+		want RGB
+		err  bool
+	}{
+		{spec: "rgb:ffff/8080/0000", want: RGB{R: 255, G: 128, B: 0}},
+		{spec: "rgb:f/8/0", want: RGB{R: 255, G: 136, B: 0}},
+		{spec: "rgb:1e/1e/2e", want: RGB{R: 30, G: 30, B: 46}},
+		{spec: "#1e1e2e", want: RGB{R: 30, G: 30, B: 46}},
+		{spec: "#fff", want: RGB{R: 255, G: 255, B: 255}},
+		{spec: "#ffff00000000", want: RGB{R: 255}},
+		{spec: "rgb:ff/ff", err: true},
+		{spec: "rgb:fffff/0/0", err: true},
+		{spec: "#ffff", err: true},
+		{spec: "red", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseXColor(tt.spec)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidColorSpec) {
+				t.Errorf("ParseXColor(%q) error = %v, want ErrInvalidColorSpec", tt.spec, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseXColor(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestDetectColorScheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		colors map[string]RGB
+		want   TerminalColors
+	}{
+		{
+			name:   "dark",
+			colors: map[string]RGB{"10": {R: 205, G: 214, B: 244}, "11": {R: 30, G: 30, B: 46}},
+			want: TerminalColors{Foreground: RGB{R: 205, G: 214, B: 244}, Background: RGB{R: 30, G: 30, B: 46},
+				HasForeground: true, Scheme: SchemeDark},
+		},
+		{
+			name:   "light",
+			colors: map[string]RGB{"10": {}, "11": {R: 255, G: 255, B: 255}},
+			want:   TerminalColors{Background: RGB{R: 255, G: 255, B: 255}, HasForeground: true, Scheme: SchemeLight},
+		},
+		{
+			name:   "background only",
+			colors: map[string]RGB{"11": {R: 250, G: 244, B: 237}},
+			want:   TerminalColors{Background: RGB{R: 250, G: 244, B: 237}, Scheme: SchemeLight},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectColorScheme(newFakeTerminal(tt.colors), time.Second)
+			if err != nil || got != tt.want {
+				t.Errorf("DetectColorScheme() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectColorSchemeErrors(t *testing.T) {
+	if _, err := DetectColorScheme(newFakeTerminal(nil), time.Second); !errors.Is(err, ErrQueryUnsupported) {
+		t.Errorf("DetectColorScheme() without color replies: error = %v, want ErrQueryUnsupported", err)
+	}
+	tty := newFakeTerminal(nil)
+	tty.silent = true
+	if _, err := DetectColorScheme(tty, 50*time.Millisecond); !errors.Is(err, ErrQueryTimeout) {
+		t.Errorf("DetectColorScheme() without replies: error = %v, want ErrQueryTimeout", err)
+	}
+}
+
+func TestQueryColors(t *testing.T) {
+	tty := newFakeTerminal(map[string]RGB{"10": {R: 1, G: 2, B: 3}, "11": {R: 4, G: 5, B: 6}})
+	tty.maxRead = 1
+	for i := 0; i < 3; i++ {
+		if got, err := QueryForegroundColor(tty, time.Second); err != nil || got != (RGB{R: 1, G: 2, B: 3}) {
+			t.Errorf("QueryForegroundColor() = %v, %v", got, err)
+		}
+		if got, err := QueryBackgroundColor(tty, time.Second); err != nil || got != (RGB{R: 4, G: 5, B: 6}) {
+			t.Errorf("QueryBackgroundColor() = %v, %v", got, err)
+		}
+	}
+	// A reader left blocked on the terminal would take the start of the next reply.
+	if n := tty.blockedReads(); n != 0 {
+		t.Errorf("%d reads of the terminal still pending after the queries", n)
+	}
+	if want := strings.Repeat("\033]10;?\033\\\033[c\033]11;?\033\\\033[c", 3); tty.written.String() != want {
+		t.Errorf("queries written = %q, want %q", tty.written.String(), want)
+	}
+}
+
+func TestQueryAfterTimeout(t *testing.T) {
+	// The first query times out while a read is pending; the reply to the second query must not be lost.
+	tty := newFakeTerminal(nil)
+	tty.silent, tty.maxRead = true, 1
+	if _, err := QueryBackgroundColor(tty, 50*time.Millisecond); !errors.Is(err, ErrQueryTimeout) {
+		t.Fatalf("QueryBackgroundColor() without replies: error = %v, want ErrQueryTimeout", err)
+	}
+	tty.mu.Lock()
+	tty.silent = false
+	tty.colors = map[string]RGB{"11": {R: 4, G: 5, B: 6}}
+	tty.mu.Unlock()
+	if got, err := QueryBackgroundColor(tty, time.Second); err != nil || got != (RGB{R: 4, G: 5, B: 6}) {
+		t.Errorf("QueryBackgroundColor() after a timeout = %v, %v", got, err)
+	}
+	if n := tty.blockedReads(); n != 0 {
+		t.Errorf("%d reads of the terminal still pending after the queries", n)
+	}
+}
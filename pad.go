@@ -0,0 +1,82 @@
+package ansicolor
+
+import (
+	"strings"
+)
+
+// Ellipsis is the tail appended by Fit to text that had to be truncated.
+const Ellipsis = "…"
+
+// PadLeft right-aligns s in a field of width terminal cells by adding spaces on its left. The visible width is
+// measured with Width, so escape sequences and wide characters do not disturb the alignment. The spaces are
+// displayed with the fill Format, or without any attributes if fill is nil. s is returned unchanged if it is
+// already at least width cells wide.
+//
+// Padding after s is displayed apart from any style or hyperlink s leaves open: they are closed before the
+// padding, so the result never leaves a style open.
+func PadLeft(s string, width int, fill *Format) string {
+	n := width - Width(s)
+	if n <= 0 {
+		return s
+	}
+	return padding(n, fill) + s
+}
+
+// PadRight left-aligns s in a field of width terminal cells by adding spaces on its right.
+// See PadLeft for the details.
+func PadRight(s string, width int, fill *Format) string {
+	n := width - Width(s)
+	if n <= 0 {
+		return s
+	}
+	return s + paddingAfter(s, n, fill)
+}
+
+// Center centers s in a field of width terminal cells by adding spaces on both sides. When the padding cannot
+// be split evenly, the extra space goes to the right. See PadLeft for the details.
+func Center(s string, width int, fill *Format) string {
+	n := width - Width(s)
+	if n <= 0 {
+		return s
+	}
+	return padding(n/2, fill) + s + paddingAfter(s, n-n/2, fill)
+}
+
+// Fit returns s left-aligned in a field of exactly width terminal cells: shorter text is padded like PadRight
+// and longer text is truncated like Truncate, ending with an Ellipsis.
+func Fit(s string, width int, fill *Format) string {
+	return PadRight(Truncate(s, width, Ellipsis), width, fill)
+}
+
+// padding returns n spaces displayed with the fill Format for the global Profile.
+func padding(n int, fill *Format) string {
+	if n <= 0 {
+		return ""
+	}
+	spaces := strings.Repeat(" ", n)
+	if fill == nil {
+		return spaces
+	}
+	set := fill.Render(GetProfile())
+	if set == "" {
+		return spaces
+	}
+	return set + spaces + ClearString
+}
+
+// paddingAfter returns the padding added after s: n spaces displayed with the fill Format, preceded by the
+// sequences closing the style s leaves open.
+func paddingAfter(s string, n int, fill *Format) string {
+	pad := padding(n, fill)
+	if pad == "" {
+		return pad
+	}
+	style := newTextStyle()
+	for _, tok := range Tokenize(s) {
+		style.update(tok)
+	}
+	if !style.isOpen() {
+		return pad
+	}
+	return style.close() + pad
+}
@@ -0,0 +1,42 @@
+package ansicolor
+
+import "testing"
+
+func TestPad(t *testing.T) {
+	defer SetProfile(GetProfile())
+	SetProfile(ProfileTrueColor)
+	blue := NewFormat().WithBackground(BgBlue)
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "left", got: PadLeft("ab", 4, nil), want: "  ab"},
+		{name: "right", got: PadRight("日本", 6, nil), want: "日本  "},
+		{name: "center", got: Center("\033[1mab\033[0m", 7, nil), want: "  \033[1mab\033[0m   "},
+		{name: "wide enough", got: PadRight("abc", 2, blue), want: "abc"},
+		{name: "fill", got: PadLeft("ab", 3, blue), want: "\033[44;22;23;24;25;27;28;29m \033[0mab"},
+		{
+			name: "open style",
+			got:  PadRight("\033[1mab", 3, blue),
+			want: "\033[1mab\033[0m\033[44;22;23;24;25;27;28;29m \033[0m",
+		},
+		{
+			name: "open style without fill",
+			got:  PadRight("\033[4mab", 3, nil),
+			want: "\033[4mab\033[0m ",
+		},
+		{
+			name: "open hyperlink",
+			got:  Center("\033]8;;http://x\033\\ab", 4, nil),
+			want: " \033]8;;http://x\033\\ab\033]8;;\033\\ ",
+		},
+		{name: "closed style", got: PadRight("\033[31mab\033[0m", 5, nil), want: "\033[31mab\033[0m   "},
+		{name: "fit", got: Fit("abcdef", 4, nil), want: "abc…"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
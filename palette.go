@@ -0,0 +1,418 @@
+package ansicolor
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidPaletteIndex indicates that a palette index is outside the range [0, 255].
+var ErrInvalidPaletteIndex = errors.New("invalid palette index")
+
+// oscPaletteColor is the OSC code addressing an entry of the 256-color palette.
+// oscCursor is the OSC code addressing the cursor color.
+// oscResetPalette resets palette entries to their configured values.
+// oscResetForeground, oscResetBackground and oscResetCursor reset the matching dynamic colors.
+const (
+	oscPaletteColor    = 4
+	oscCursor          = 12
+	oscResetPalette    = 104
+	oscResetForeground = 110
+	oscResetBackground = 111
+	oscResetCursor     = 112
+)
+
+// Palette holds the RGB values a terminal uses to display the 16 standard colors, along with its default
+// foreground, background and cursor colors. Colors is indexed like the palette: 0-7 are the standard colors
+// black through white and 8-15 their bright variants.
+type Palette struct {
+	Colors     [16]RGB
+	Foreground RGB
+	Background RGB
+	Cursor     RGB
+}
+
+// DefaultPalette is the default xterm palette. It is used whenever the actual palette is unknown.
+var DefaultPalette = Palette{
+	Colors: [16]RGB{
+		{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+		{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+		{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+		{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+	},
+	Foreground: RGB{229, 229, 229},
+	Background: RGB{0, 0, 0},
+	Cursor:     RGB{229, 229, 229},
+}
+
+// Fg returns the RGB value the palette displays for the provided foreground color.
+// FgDefault maps to the palette's foreground color.
+func (p *Palette) Fg(c FgColor) RGB {
+	if i := c.PaletteIndex(); i >= 0 {
+		return p.Colors[i]
+	}
+	return p.Foreground
+}
+
+// Bg returns the RGB value the palette displays for the provided background color.
+// BgDefault maps to the palette's background color.
+func (p *Palette) Bg(c BgColor) RGB {
+	if i := c.PaletteIndex(); i >= 0 {
+		return p.Colors[i]
+	}
+	return p.Background
+}
+
+// Color returns the RGB value the palette displays for an extended color. RGB colors are returned unchanged.
+func (p *Palette) Color(c Color) RGB {
+	if c.isRGB {
+		return c.rgb
+	}
+	return palette256RGB(c.index, p)
+}
+
+// FormatColors returns the foreground and background colors the palette displays for text in the provided
+// Format, taking reverse video into account. fgSet and bgSet report whether the colors differ from the
+// palette's defaults because of the Format.
+func (p *Palette) FormatColors(f *Format) (fg, bg RGB, fgSet, bgSet bool) {
+	fg, bg = p.Foreground, p.Background
+	switch {
+	case f.fgx != nil:
+		fg, fgSet = p.Color(*f.fgx), true
+	case f.fg != nil && *f.fg != FgDefault:
+		fg, fgSet = p.Fg(*f.fg), true
+	}
+	switch {
+	case f.bgx != nil:
+		bg, bgSet = p.Color(*f.bgx), true
+	case f.bg != nil && *f.bg != BgDefault:
+		bg, bgSet = p.Bg(*f.bg), true
+	}
+	if f.opts.Has(SGROptReverse) {
+		return bg, fg, true, true
+	}
+	return fg, bg, fgSet, bgSet
+}
+
+// PaletteIndex returns the palette entry (0-15) used to display the FgColor, or -1 for FgDefault and
+// invalid colors.
+func (c FgColor) PaletteIndex() int {
+	switch {
+	case c >= FgBlack && c <= FgWhite:
+		return int(c - FgBlack)
+	case c >= FgBrightBlack && c <= FgBrightWhite:
+		return int(c-FgBrightBlack) + 8
+	}
+	return -1
+}
+
+// PaletteIndex returns the palette entry (0-15) used to display the BgColor, or -1 for BgDefault and
+// invalid colors.
+func (b BgColor) PaletteIndex() int {
+	switch {
+	case b >= BgBlack && b <= BgWhite:
+		return int(b - BgBlack)
+	case b >= BgBrightBlack && b <= BgBrightWhite:
+		return int(b-BgBrightBlack) + 8
+	}
+	return -1
+}
+
+// QueryPaletteColor asks the terminal for the RGB value of a palette entry (0-255) using OSC 4.
+// The terminal must be in raw mode; see DetectColorScheme.
+func QueryPaletteColor(tty io.ReadWriter, index int, timeout time.Duration) (RGB, error) {
+	if index < 0 || index > 255 {
+		return RGB{}, ErrInvalidPaletteIndex
+	}
+	return queryOSCColor(tty, timeout, paletteSlot(index))
+}
+
+// QueryPalette asks the terminal for its 16 standard colors and its default foreground, background and cursor
+// colors in a single round trip. Entries the terminal does not report are taken from DefaultPalette.
+// Returns ErrQueryUnsupported if the terminal reports none of them.
+func QueryPalette(tty io.ReadWriter, timeout time.Duration) (Palette, error) {
+	slots := make([]string, 0, 19)
+	for i := 0; i < 16; i++ {
+		slots = append(slots, paletteSlot(i))
+	}
+	fg, bg, cursor := strconv.Itoa(oscForeground), strconv.Itoa(oscBackground), strconv.Itoa(oscCursor)
+	slots = append(slots, fg, bg, cursor)
+
+	colors, err := queryOSCColors(tty, timeout, slots...)
+	if err != nil {
+		return DefaultPalette, err
+	}
+	if len(colors) == 0 {
+		return DefaultPalette, ErrQueryUnsupported
+	}
+	p := DefaultPalette
+	for i := range p.Colors {
+		if c, ok := colors[slots[i]]; ok {
+			p.Colors[i] = c
+		}
+	}
+	if c, ok := colors[fg]; ok {
+		p.Foreground = c
+	}
+	if c, ok := colors[bg]; ok {
+		p.Background = c
+	}
+	if c, ok := colors[cursor]; ok {
+		p.Cursor = c
+	}
+	return p, nil
+}
+
+// SetPaletteColor changes the RGB value of a palette entry (0-255) using OSC 4.
+func SetPaletteColor(w io.Writer, index int, c RGB) error {
+	if index < 0 || index > 255 {
+		return ErrInvalidPaletteIndex
+	}
+	return writeOSC(w, paletteSlot(index)+";"+c.XColor())
+}
+
+// ResetPaletteColor restores a palette entry (0-255) to the value configured in the terminal using OSC 104.
+func ResetPaletteColor(w io.Writer, index int) error {
+	if index < 0 || index > 255 {
+		return ErrInvalidPaletteIndex
+	}
+	return writeOSC(w, strconv.Itoa(oscResetPalette)+";"+strconv.Itoa(index))
+}
+
+// ResetPalette restores every palette entry to the value configured in the terminal.
+func ResetPalette(w io.Writer) error {
+	return writeOSC(w, strconv.Itoa(oscResetPalette))
+}
+
+// SetForegroundColor changes the terminal's default foreground color using OSC 10.
+func SetForegroundColor(w io.Writer, c RGB) error {
+	return writeOSC(w, strconv.Itoa(oscForeground)+";"+c.XColor())
+}
+
+// ResetForegroundColor restores the terminal's configured default foreground color.
+func ResetForegroundColor(w io.Writer) error {
+	return writeOSC(w, strconv.Itoa(oscResetForeground))
+}
+
+// SetBackgroundColor changes the terminal's default background color using OSC 11.
+func SetBackgroundColor(w io.Writer, c RGB) error {
+	return writeOSC(w, strconv.Itoa(oscBackground)+";"+c.XColor())
+}
+
+// ResetBackgroundColor restores the terminal's configured default background color.
+func ResetBackgroundColor(w io.Writer) error {
+	return writeOSC(w, strconv.Itoa(oscResetBackground))
+}
+
+// SetCursorColor changes the terminal's cursor color using OSC 12.
+func SetCursorColor(w io.Writer, c RGB) error {
+	return writeOSC(w, strconv.Itoa(oscCursor)+";"+c.XColor())
+}
+
+// ResetCursorColor restores the terminal's configured cursor color.
+func ResetCursorColor(w io.Writer) error {
+	return writeOSC(w, strconv.Itoa(oscResetCursor))
+}
+
+// paletteSlot returns the OSC 4 slot addressing the palette entry at index.
+func paletteSlot(index int) string {
+	return strconv.Itoa(oscPaletteColor) + ";" + strconv.Itoa(index)
+}
+
+// writeOSC writes an Operating System Command with the provided payload.
+func writeOSC(w io.Writer, payload string) error {
+	_, err := io.WriteString(w, StartOSC+payload+EndOSC)
+	return err
+}
+
+// PaletteGuard changes terminal colors and remembers what it changed so that every change can be undone with
+// Restore. The guard queries each color before changing it for the first time and Restore writes the queried value
+// back; colors the terminal did not report are reset to the value configured in the terminal instead.
+//
+// Call Close (typically deferred) when done, and RestoreOnSignal to also restore the colors when the program is
+// interrupted. A PaletteGuard is safe for concurrent use.
+type PaletteGuard struct {
+	tty     io.ReadWriter
+	timeout time.Duration
+	mu      sync.Mutex
+	saved   map[string]savedColor // by OSC slot, such as "4;1" or "11"
+	sigs    chan os.Signal
+	stop    chan struct{}
+}
+
+// savedColor is the value a color had before a PaletteGuard changed it.
+type savedColor struct {
+	rgb   RGB
+	known bool // false if the terminal did not report the color
+}
+
+// NewPaletteGuard creates a new PaletteGuard for the provided terminal, waiting at most timeout for the terminal to
+// report a color before changing it. The terminal must be in raw mode; see DetectColorScheme. With a zero timeout,
+// colors are not queried and Restore resets them to the values configured in the terminal.
+func NewPaletteGuard(tty io.ReadWriter, timeout time.Duration) *PaletteGuard {
+	return &PaletteGuard{
+		tty:     tty,
+		timeout: timeout,
+		saved:   make(map[string]savedColor),
+	}
+}
+
+// SetPaletteColor changes a palette entry (0-255) and records it for restoration.
+func (g *PaletteGuard) SetPaletteColor(index int, c RGB) error {
+	if index < 0 || index > 255 {
+		return ErrInvalidPaletteIndex
+	}
+	return g.set(paletteSlot(index), c)
+}
+
+// SetForegroundColor changes the default foreground color and records it for restoration.
+func (g *PaletteGuard) SetForegroundColor(c RGB) error {
+	return g.set(strconv.Itoa(oscForeground), c)
+}
+
+// SetBackgroundColor changes the default background color and records it for restoration.
+func (g *PaletteGuard) SetBackgroundColor(c RGB) error {
+	return g.set(strconv.Itoa(oscBackground), c)
+}
+
+// SetCursorColor changes the cursor color and records it for restoration.
+func (g *PaletteGuard) SetCursorColor(c RGB) error {
+	return g.set(strconv.Itoa(oscCursor), c)
+}
+
+// set changes the color of an OSC slot, saving its current value the first time.
+func (g *PaletteGuard) set(slot string, c RGB) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.saved[slot]; !ok {
+		var saved savedColor
+		if g.timeout > 0 {
+			if rgb, err := queryOSCColor(g.tty, g.timeout, slot); err == nil {
+				saved = savedColor{rgb: rgb, known: true}
+			}
+		}
+		g.saved[slot] = saved
+	}
+	return writeOSC(g.tty, slot+";"+c.XColor())
+}
+
+// Restore puts back every color changed through the guard and forgets about the changes.
+func (g *PaletteGuard) Restore() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	slots := make([]string, 0, len(g.saved))
+	for slot := range g.saved {
+		slots = append(slots, slot)
+	}
+	// Palette entries by index, then the foreground, background and cursor colors.
+	sort.Slice(slots, func(i, j int) bool {
+		ci, ii := splitSlot(slots[i])
+		cj, ij := splitSlot(slots[j])
+		return ci < cj || ci == cj && ii < ij
+	})
+	var errs []error
+	for _, slot := range slots {
+		if c := g.saved[slot]; c.known {
+			errs = append(errs, writeOSC(g.tty, slot+";"+c.rgb.XColor()))
+		} else {
+			errs = append(errs, resetSlot(g.tty, slot))
+		}
+	}
+	g.saved = make(map[string]savedColor)
+	return errors.Join(errs...)
+}
+
+// splitSlot returns the OSC code and the palette index of an OSC slot; the index is -1 for dynamic colors.
+func splitSlot(slot string) (code, index int) {
+	c, i, ok := strings.Cut(slot, ";")
+	code, _ = strconv.Atoi(c)
+	index = -1
+	if ok {
+		index, _ = strconv.Atoi(i)
+	}
+	return code, index
+}
+
+// resetSlot resets the color of an OSC slot to the value configured in the terminal.
+func resetSlot(w io.Writer, slot string) error {
+	switch code, index := splitSlot(slot); code {
+	case oscPaletteColor:
+		return ResetPaletteColor(w, index)
+	case oscForeground:
+		return ResetForegroundColor(w)
+	case oscBackground:
+		return ResetBackgroundColor(w)
+	}
+	return ResetCursorColor(w)
+}
+
+// RestoreOnSignal restores the colors when one of the provided signals (os.Interrupt if none are given) is
+// received, then stops listening. The guard consumes the signal: while it listens, the default handling of the
+// signal, such as terminating the program, does not apply. With reraise set, the signal is raised again once the
+// colors are restored, so that the default handling applies; leave it unset if the program handles the signal
+// with signal.Notify, as its handler would otherwise receive the signal twice, and call Close from that handler
+// instead. Calling it again replaces the previous set of signals.
+func (g *PaletteGuard) RestoreOnSignal(reraise bool, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt}
+	}
+	g.stopSignals()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ch, stop := make(chan os.Signal, 1), make(chan struct{})
+	g.sigs, g.stop = ch, stop
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			_ = g.Restore()
+			g.stopChannel(ch)
+			if !reraise {
+				return
+			}
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				if p.Signal(sig) == nil {
+					return
+				}
+			}
+			os.Exit(1)
+		case <-stop:
+		}
+	}()
+}
+
+// Close restores the colors and stops listening for signals registered with RestoreOnSignal.
+func (g *PaletteGuard) Close() error {
+	g.stopSignals()
+	return g.Restore()
+}
+
+// stopSignals unregisters the signal handler installed by RestoreOnSignal, if any.
+func (g *PaletteGuard) stopSignals() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.sigs == nil {
+		return
+	}
+	signal.Stop(g.sigs)
+	close(g.stop)
+	g.sigs, g.stop = nil, nil
+}
+
+// stopChannel stops the delivery of signals to ch, a channel registered by RestoreOnSignal, unregistering the
+// handler if it is still the current one.
+func (g *PaletteGuard) stopChannel(ch chan os.Signal) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	signal.Stop(ch)
+	if g.sigs == ch {
+		g.sigs, g.stop = nil, nil
+	}
+}
@@ -0,0 +1,122 @@
+package ansicolor
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaletteGuardRestore(t *testing.T) {
+	red, blue := RGB{R: 0xcc, G: 0x24, B: 0x1d}, RGB{R: 0x28, G: 0x2c, B: 0x34}
+	tty := newFakeTerminal(map[string]RGB{"4;1": red, "11": blue})
+	g := NewPaletteGuard(tty, time.Second)
+	for _, set := range []func() error{
+		func() error { return g.SetPaletteColor(3, RGB{R: 255}) },
+		func() error { return g.SetPaletteColor(1, RGB{G: 255}) },
+		func() error { return g.SetBackgroundColor(RGB{}) },
+		func() error { return g.SetPaletteColor(1, RGB{B: 255}) },
+	} {
+		if err := set(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.SetPaletteColor(256, RGB{}); err != ErrInvalidPaletteIndex {
+		t.Errorf("SetPaletteColor(256) error = %v, want ErrInvalidPaletteIndex", err)
+	}
+	// Each color is queried once, before its first change.
+	if got := strings.Count(tty.written.String(), "\033]4;1;?"); got != 1 {
+		t.Errorf("palette entry 1 queried %d times, want once", got)
+	}
+
+	tty.written.Reset()
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Reported colors are written back; entry 3, which the terminal did not report, is reset.
+	want := "\033]4;1;" + red.XColor() + "\033\\\033]104;3\033\\\033]11;" + blue.XColor() + "\033\\"
+	if tty.written.String() != want {
+		t.Errorf("Close() wrote %q, want %q", tty.written.String(), want)
+	}
+	tty.written.Reset()
+	if err := g.Restore(); err != nil || tty.written.Len() != 0 {
+		t.Errorf("second Restore() wrote %q, %v", tty.written.String(), err)
+	}
+}
+
+func TestPaletteGuardNoQuery(t *testing.T) {
+	// Without a timeout, nothing is read and the colors are reset to the configured values.
+	var buf bytes.Buffer
+	g := NewPaletteGuard(&buf, 0)
+	_ = g.SetCursorColor(RGB{R: 255})
+	_ = g.SetForegroundColor(RGB{R: 255})
+	_ = g.SetPaletteColor(12, RGB{R: 255})
+	_ = g.SetPaletteColor(2, RGB{R: 255})
+	buf.Reset()
+	if err := g.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\033]104;2\033\\\033]104;12\033\\\033]110\033\\\033]112\033\\"; buf.String() != want {
+		t.Errorf("Restore() wrote %q, want %q", buf.String(), want)
+	}
+}
+
+// TestPaletteGuardRestoreOnSignal checks that the guard consumes the signal unless told to re-raise it, and that
+// the signal handler of the application keeps working after the guard has restored the colors.
+func TestPaletteGuardRestoreOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending os.Interrupt is not supported on Windows")
+	}
+	app := make(chan os.Signal, 4)
+	signal.Notify(app, os.Interrupt)
+	defer signal.Stop(app)
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reraise := range []bool{false, true} {
+		var buf bytes.Buffer
+		g := NewPaletteGuard(&buf, 0)
+		if err := g.SetForegroundColor(RGB{R: 255}); err != nil {
+			t.Fatal(err)
+		}
+		g.RestoreOnSignal(reraise)
+		if err := p.Signal(os.Interrupt); err != nil {
+			t.Fatal(err)
+		}
+		restored := func() bool {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return strings.HasSuffix(buf.String(), "\033]110\033\\") && g.sigs == nil
+		}
+		for deadline := time.Now().Add(5 * time.Second); !restored(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("colors not restored after the signal")
+			}
+		}
+		want := 1
+		if reraise {
+			want = 2
+		}
+		time.Sleep(50 * time.Millisecond)
+		if len(app) != want {
+			t.Errorf("RestoreOnSignal(%v): application received the signal %d times, want %d", reraise, len(app), want)
+		}
+		for len(app) > 0 {
+			<-app
+		}
+	}
+
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-app:
+	case <-time.After(5 * time.Second):
+		t.Fatal("application handler no longer receives the signal")
+	}
+}
@@ -0,0 +1,249 @@
+package ansicolor
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StartDCS defines the starting sequence for a Device Control String.
+// EndDCS defines the string terminator (ST) that ends a Device Control String.
+const (
+	StartDCS = "\033P"
+	EndDCS   = "\033\\"
+)
+
+// probeColor is the RGB value set and read back to detect 24-bit color support. It is unlikely to be
+// approximated by a terminal that only pretends to support 24-bit colors.
+var probeColor = RGB{R: 1, G: 2, B: 3}
+
+// probedCapabilities lists the terminfo capabilities requested with XTGETTCAP when building a Profile.
+var probedCapabilities = []string{"colors", "Tc", "RGB", "sitm", "smxx", "Smulx"}
+
+// Prober asks a terminal directly for its capabilities using XTGETTCAP and DECRQSS, for terminals whose terminfo
+// entry is missing or incomplete. Answers are cached, so each capability is only requested once.
+//
+// The terminal must be in raw mode while probing; see DetectColorScheme. A Prober is safe for concurrent use.
+type Prober struct {
+	tty       io.ReadWriter
+	timeout   time.Duration
+	mu        sync.Mutex
+	caps      map[string]*string // nil value: the terminal does not know the capability
+	trueColor *bool
+}
+
+// NewProber creates a new Prober for the provided terminal, waiting at most timeout for each round trip.
+func NewProber(tty io.ReadWriter, timeout time.Duration) *Prober {
+	return &Prober{
+		tty:     tty,
+		timeout: timeout,
+		caps:    make(map[string]*string),
+	}
+}
+
+// Capability requests a terminfo capability from the terminal using XTGETTCAP. It returns the capability value
+// (empty for boolean capabilities) and whether the terminal knows the capability.
+func (p *Prober) Capability(name string) (string, bool, error) {
+	caps, err := p.Capabilities(name)
+	if err != nil {
+		return "", false, err
+	}
+	v, ok := caps[name]
+	return v, ok, nil
+}
+
+// Capabilities requests several terminfo capabilities from the terminal in one round trip using XTGETTCAP.
+// The returned map only holds the capabilities the terminal knows; numeric capabilities are returned in
+// decimal and boolean capabilities as empty strings.
+func (p *Prober) Capabilities(names ...string) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+	var pending []string
+	for _, name := range names {
+		if _, ok := p.caps[name]; ok || name == "" {
+			continue
+		}
+		pending = append(pending, name)
+		// Terminals stop at the first unknown name of a combined request, so every name is sent on its own.
+		b.WriteString(StartDCS)
+		b.WriteString("+q")
+		b.WriteString(hex.EncodeToString([]byte(name)))
+		b.WriteString(EndDCS)
+	}
+	if len(pending) > 0 {
+		replies, err := queryTerminal(p.tty, b.String(), p.timeout)
+		if err != nil {
+			return nil, err
+		}
+		for _, reply := range replies {
+			if name, value, ok := parseXTGETTCAP(reply); ok {
+				v := value
+				p.caps[name] = &v
+			}
+		}
+		// Names without a valid reply are unknown to the terminal.
+		for _, name := range pending {
+			if _, ok := p.caps[name]; !ok {
+				p.caps[name] = nil
+			}
+		}
+	}
+
+	caps := make(map[string]string, len(names))
+	for _, name := range names {
+		if v := p.caps[name]; v != nil {
+			caps[name] = *v
+		}
+	}
+	return caps, nil
+}
+
+// parseXTGETTCAP decodes an XTGETTCAP reply of the form DCS 1+r<hex name>=<hex value> ST.
+func parseXTGETTCAP(reply Token) (string, string, bool) {
+	if reply.Kind != TokenDCS || reply.Params != "1" || reply.Intermediates != "+" || reply.Final != 'r' {
+		return "", "", false
+	}
+	encName, encValue, _ := strings.Cut(reply.Data, "=")
+	name, err := hex.DecodeString(encName)
+	if err != nil || len(name) == 0 {
+		return "", "", false
+	}
+	value, err := hex.DecodeString(encValue)
+	if err != nil {
+		return "", "", false
+	}
+	return string(name), string(value), true
+}
+
+// SGRState requests the terminal's current SGR parameters using DECRQSS and returns them as reported, for
+// example "0;1;38:2::255:0:0". Returns ErrQueryUnsupported if the terminal does not answer the request.
+func (p *Prober) SGRState() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sgrState("")
+}
+
+// sgrState writes prefix, then requests the current SGR parameters using DECRQSS.
+func (p *Prober) sgrState(prefix string) (string, error) {
+	replies, err := queryTerminal(p.tty, prefix+StartDCS+"$qm"+EndDCS, p.timeout)
+	if err != nil {
+		return "", err
+	}
+	for _, reply := range replies {
+		if reply.Kind != TokenDCS || reply.Intermediates != "$" || reply.Final != 'r' {
+			continue
+		}
+		// DEC documents 0 as the valid status while xterm and most emulators reply with 1, so the status is
+		// ignored and a well-formed parameter string is taken as the answer instead.
+		params := reply.Data
+		if !strings.HasSuffix(params, "m") {
+			continue
+		}
+		return strings.TrimSuffix(params, "m"), nil
+	}
+	return "", ErrQueryUnsupported
+}
+
+// SupportsTrueColor detects 24-bit color support by setting an RGB background color, reading the SGR state back
+// with DECRQSS and checking that the color survived unchanged. The SGR state is read before the test and written
+// back afterwards, so the attributes in effect are left unchanged; nothing is written if the terminal does not
+// answer DECRQSS.
+func (p *Prober) SupportsTrueColor() (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.trueColor != nil {
+		return *p.trueColor, nil
+	}
+
+	supported := false
+	prev, err := p.sgrState("")
+	if err == nil {
+		set := StartFormat + TrueColor(probeColor.R, probeColor.G, probeColor.B).bgShort() + EndFormat
+		var state string
+		state, err = p.sgrState(set)
+		_, _ = io.WriteString(p.tty, StartFormat+"0;"+prev+EndFormat)
+		supported = err == nil && sgrHasRGB(state, "48", probeColor)
+	}
+	if err != nil && !errors.Is(err, ErrQueryUnsupported) {
+		return false, err
+	}
+	p.trueColor = &supported
+	return supported, nil
+}
+
+// sgrHasRGB reports whether the SGR parameters contain the RGB color c for the color code (38, 48 or 58),
+// in either the colon or the semicolon notation.
+func sgrHasRGB(params, code string, c RGB) bool {
+	r, g, b := strconv.Itoa(int(c.R)), strconv.Itoa(int(c.G)), strconv.Itoa(int(c.B))
+	for _, form := range []string{
+		code + ":2::" + r + ":" + g + ":" + b,
+		code + ":2:" + r + ":" + g + ":" + b,
+		code + ";2;" + r + ";" + g + ";" + b,
+	} {
+		if strings.Contains(params, form) {
+			return true
+		}
+	}
+	return false
+}
+
+// Profile refines the base Profile with the answers of the terminal: XTGETTCAP reports the number of colors and
+// capabilities such as Tc, RGB, sitm and smxx, and a DECRQSS round trip confirms 24-bit color support. Probing
+// only ever adds colors and options to the base profile.
+func (p *Prober) Profile(base Profile) (Profile, error) {
+	caps, err := p.Capabilities(probedCapabilities...)
+	if err != nil {
+		return base, err
+	}
+	level := base.Colors
+	if v, ok := caps["colors"]; ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			level = maxLevel(level, levelForColors(n))
+		}
+	}
+	_, tc := caps["Tc"]
+	_, rgb := caps["RGB"]
+	if tc || rgb {
+		level = LevelTrueColor
+	}
+	if level < LevelTrueColor {
+		if ok, err := p.SupportsTrueColor(); err == nil && ok {
+			level = LevelTrueColor
+		}
+	}
+	base.Colors = level
+
+	for opt, name := range terminfoOptionCaps {
+		if _, ok := caps[name]; ok {
+			base.Options.Set(opt)
+		}
+	}
+	return base, nil
+}
+
+// maxLevel returns the higher of two color levels.
+func maxLevel(a, b ColorLevel) ColorLevel {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ProbeProfile refines EnvProfile() by probing the terminal with a Prober and installs the result with
+// SetProfile, so that formats rendered afterwards use the probed capabilities. The environment-derived profile
+// is installed if probing fails, and NO_COLOR is honored either way.
+func ProbeProfile(tty io.ReadWriter, timeout time.Duration) (Profile, error) {
+	p, err := NewProber(tty, timeout).Profile(EnvProfile())
+	if os.Getenv("NO_COLOR") != "" {
+		p.Colors = LevelNoColor
+	}
+	SetProfile(p)
+	return p, err
+}
@@ -0,0 +1,139 @@
+package ansicolor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProberCapabilities(t *testing.T) {
+	tty := newFakeTerminal(nil)
+	tty.caps = map[string]string{"colors": "256", "Tc": "", "sitm": "\033[3m"}
+	p := NewProber(tty, time.Second)
+	caps, err := p.Capabilities("colors", "Tc", "smxx", "sitm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"colors": "256", "Tc": "", "sitm": "\033[3m"}
+	if len(caps) != len(want) {
+		t.Errorf("Capabilities() = %q, want %q", caps, want)
+	}
+	for name, v := range want {
+		if got, ok := caps[name]; !ok || got != v {
+			t.Errorf("Capabilities()[%q] = %q, %v, want %q", name, got, ok, v)
+		}
+	}
+
+	// Answers are cached, including the unknown capabilities.
+	written := tty.written.Len()
+	if v, ok, err := p.Capability("colors"); err != nil || !ok || v != "256" {
+		t.Errorf("Capability(colors) = %q, %v, %v", v, ok, err)
+	}
+	if _, ok, err := p.Capability("smxx"); err != nil || ok {
+		t.Errorf("Capability(smxx) = %v, %v, want unknown", ok, err)
+	}
+	if tty.written.Len() != written {
+		t.Errorf("cached capabilities were requested again: %q", tty.written.String()[written:])
+	}
+}
+
+func TestProberMalformedReplies(t *testing.T) {
+	tty := newFakeTerminal(nil)
+	tty.noise = "\033P1+rZZ=3235\033\\" + // name is not hex
+		"\033P1+r636f6c6f7273=XY\033\\" + // value is not hex
+		"\033P1+r=3235\033\\" + // empty name
+		"\033P0+r636f6c6f7273=3235\033\\" + // invalid request status
+		"\033P1$r0;1\033\\" + // SGR state without final m
+		"\033[1;31mnoise\r\n"
+	p := NewProber(tty, time.Second)
+	if v, ok, err := p.Capability("colors"); err != nil || ok {
+		t.Errorf("Capability(colors) = %q, %v, %v, want unknown", v, ok, err)
+	}
+	if state, err := p.SGRState(); !errors.Is(err, ErrQueryUnsupported) {
+		t.Errorf("SGRState() = %q, %v, want ErrQueryUnsupported", state, err)
+	}
+}
+
+func TestProberSupportsTrueColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		decrqss bool
+		approx  bool
+		want    bool
+	}{
+		{name: "24-bit", decrqss: true, want: true},
+		{name: "approximated", decrqss: true, approx: true},
+		{name: "no DECRQSS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tty := newFakeTerminal(nil)
+			tty.decrqss, tty.approx = tt.decrqss, tt.approx
+			// The background and options set by the application survive the probe.
+			_, _ = tty.Write([]byte("\033[1;44m"))
+			p := NewProber(tty, time.Second)
+			if got, err := p.SupportsTrueColor(); err != nil || got != tt.want {
+				t.Errorf("SupportsTrueColor() = %v, %v, want %v", got, err, tt.want)
+			}
+			want := NewFormat().WithOption(SGROptBold).WithBackground(BgBlue)
+			if got := NewFormat().ApplySGR(tty.sgr); !got.Equal(want) {
+				t.Errorf("SGR state after SupportsTrueColor() = %q, want %q", tty.sgr, "0;1;44")
+			}
+			written := tty.written.Len()
+			if got, err := p.SupportsTrueColor(); err != nil || got != tt.want || tty.written.Len() != written {
+				t.Errorf("second SupportsTrueColor() = %v, %v, want a cached %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProberProfile(t *testing.T) {
+	base := Profile{Colors: LevelANSI, Options: SGROptBold}
+	tests := []struct {
+		name    string
+		caps    map[string]string
+		decrqss bool
+		want    Profile
+	}{
+		{name: "nothing known", caps: map[string]string{}, want: base},
+		{name: "256 colors", caps: map[string]string{"colors": "256", "smxx": "\033[9m"},
+			want: Profile{Colors: LevelANSI256, Options: SGROptBold | SGROptStrike}},
+		{name: "Tc", caps: map[string]string{"colors": "8", "Tc": "", "sitm": "\033[3m"},
+			want: Profile{Colors: LevelTrueColor, Options: SGROptBold | SGROptItalic}},
+		{name: "DECRQSS", caps: map[string]string{"colors": "256"}, decrqss: true,
+			want: Profile{Colors: LevelTrueColor, Options: SGROptBold}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tty := newFakeTerminal(nil)
+			tty.caps, tty.decrqss = tt.caps, tt.decrqss
+			got, err := NewProber(tty, time.Second).Profile(base)
+			if err != nil || got != tt.want {
+				t.Errorf("Profile() = %+v, %v, want %+v", got, err, tt.want)
+			}
+			// Every round trip of the probe must leave the terminal without a pending read.
+			if n := tty.blockedReads(); n != 0 {
+				t.Errorf("%d reads of the terminal still pending after probing", n)
+			}
+		})
+	}
+}
+
+func TestProberTimeout(t *testing.T) {
+	tty := newFakeTerminal(nil)
+	tty.silent = true
+	p := NewProber(tty, 50*time.Millisecond)
+	if _, err := p.Capabilities("colors"); !errors.Is(err, ErrQueryTimeout) {
+		t.Errorf("Capabilities() error = %v, want ErrQueryTimeout", err)
+	}
+	if _, err := p.SupportsTrueColor(); !errors.Is(err, ErrQueryTimeout) {
+		t.Errorf("SupportsTrueColor() error = %v, want ErrQueryTimeout", err)
+	}
+	base := Profile{Colors: LevelANSI}
+	if got, err := p.Profile(base); !errors.Is(err, ErrQueryTimeout) || got != base {
+		t.Errorf("Profile() = %+v, %v, want the base profile and ErrQueryTimeout", got, err)
+	}
+	if Strip(tty.written.String()) != "" || tty.sgr != "0" {
+		t.Errorf("SGR state changed by a probe without replies: %q", tty.written.String())
+	}
+}
@@ -0,0 +1,180 @@
+package ansicolor
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorLevel describes the range of colors a terminal can display.
+type ColorLevel int
+
+// LevelNoColor means the terminal cannot display colors.
+// LevelBasic means the terminal can display the 8 standard colors.
+// LevelANSI means the terminal can display the 8 standard colors and their bright variants.
+// LevelANSI256 means the terminal can display the 256-color palette.
+// LevelTrueColor means the terminal can display 24-bit RGB colors.
+const (
+	LevelNoColor ColorLevel = iota
+	LevelBasic
+	LevelANSI
+	LevelANSI256
+	LevelTrueColor
+)
+
+// String returns the name of the ColorLevel.
+func (l ColorLevel) String() string {
+	switch l {
+	case LevelNoColor:
+		return "no color"
+	case LevelBasic:
+		return "8 colors"
+	case LevelANSI:
+		return "16 colors"
+	case LevelANSI256:
+		return "256 colors"
+	case LevelTrueColor:
+		return "true color"
+	}
+	return ""
+}
+
+// Profile describes the colors and SGR options a terminal can display. Formats rendered for a Profile
+// approximate unsupported colors with the closest supported ones and leave out unsupported options.
+type Profile struct {
+	Colors  ColorLevel
+	Options SGROption
+}
+
+// ProfileTrueColor supports every color and option. It is the default profile.
+// ProfilePlain supports neither colors nor options; formats rendered for it produce no escape sequences.
+var (
+	ProfileTrueColor = Profile{Colors: LevelTrueColor, Options: SGROptAll}
+	ProfilePlain     = Profile{}
+)
+
+// profile is the Profile used when formats are written to the terminal. It is not derived from the environment
+// so that importing the package has no side effects.
+var profile = ProfileTrueColor
+
+// GetProfile returns the Profile used by Set, Wrap and the other functions that write formats.
+// This value can be set globally with SetProfile(); it is ProfileTrueColor until then, whatever the terminal.
+// Programs should call SetProfile(EnvProfile()) at startup to match the terminal they run in.
+func GetProfile() Profile {
+	return profile
+}
+
+// SetProfile sets the global Profile used by Set, Wrap and the other functions that write formats.
+func SetProfile(p Profile) {
+	profile = p
+}
+
+// WriterProfile returns the Profile to use for output written to w: the global Profile if w is a terminal and
+// ProfilePlain otherwise, so that output redirected to a file, a pipe or a device such as /dev/null contains no
+// escape sequences.
+func WriterProfile(w io.Writer) Profile {
+	f, ok := w.(*os.File)
+	if !ok || !isTerminal(f.Fd()) {
+		return ProfilePlain
+	}
+	return GetProfile()
+}
+
+// IsPlain reports whether the Profile supports neither colors nor options.
+func (p Profile) IsPlain() bool {
+	return p.Colors == LevelNoColor && p.Options == 0
+}
+
+// terminfoOptionCaps maps SGR options to the terminfo capabilities that indicate support for them.
+var terminfoOptionCaps = map[SGROption]string{
+	SGROptBold:            "bold",
+	SGROptFaint:           "dim",
+	SGROptItalic:          "sitm",
+	SGROptUnderline:       "smul",
+	SGROptBlink:           "blink",
+	SGROptReverse:         "rev",
+	SGROptConceal:         "invis",
+	SGROptStrike:          "smxx",
+	SGROptDoubleUnderline: "Smulx",
+}
+
+// ProfileFromTerminfo derives a Profile from the capabilities of a terminfo entry: the colors, setaf, Tc and
+// RGB capabilities determine the color level, and capabilities such as bold, sitm and smul the options.
+func ProfileFromTerminfo(ti *Terminfo) Profile {
+	var p Profile
+	switch {
+	case ti.TrueColor():
+		p.Colors = LevelTrueColor
+	case ti.Has("setaf") || ti.Has("setf"):
+		p.Colors = levelForColors(ti.Colors())
+	}
+	for opt, name := range terminfoOptionCaps {
+		if ti.Has(name) {
+			p.Options.Set(opt)
+		}
+	}
+	if p.Options.Has(SGROptBlink) {
+		// terminfo has no capability for rapid blink; terminals that do not support it fall back to blink.
+		p.Options.Set(SGROptFastBlink)
+	}
+	return p
+}
+
+// levelForColors returns the ColorLevel of a terminal that supports n colors.
+func levelForColors(n int) ColorLevel {
+	switch {
+	case n >= 1<<24:
+		return LevelTrueColor
+	case n >= 256:
+		return LevelANSI256
+	case n >= 16:
+		return LevelANSI
+	case n >= 8:
+		return LevelBasic
+	}
+	return LevelNoColor
+}
+
+// ProfileForTerm returns the Profile of the terminal type term (a $TERM value) according to its terminfo entry.
+// Returns ErrTerminfoNotFound if the terminal type is unknown.
+func ProfileForTerm(term string) (Profile, error) {
+	ti, err := LoadTerminfo(term)
+	if err != nil {
+		return ProfilePlain, err
+	}
+	return ProfileFromTerminfo(ti), nil
+}
+
+// EnvProfile derives a Profile from the environment, typically to be installed with SetProfile. $TERM is
+// resolved through terminfo, falling back to guessing from the name when no entry exists; COLORTERM=truecolor or
+// 24bit raises the color level to LevelTrueColor, and a non-empty NO_COLOR disables colors while keeping options.
+func EnvProfile() Profile {
+	term := os.Getenv("TERM")
+	p, err := ProfileForTerm(term)
+	if err != nil {
+		p = guessProfile(term)
+	}
+	if p.Colors > LevelNoColor {
+		switch strings.ToLower(os.Getenv("COLORTERM")) {
+		case "truecolor", "24bit":
+			p.Colors = LevelTrueColor
+		}
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		p.Colors = LevelNoColor
+	}
+	return p
+}
+
+// guessProfile estimates the Profile of a terminal type that has no terminfo entry from its name.
+func guessProfile(term string) Profile {
+	switch {
+	case term == "" || term == "dumb":
+		return ProfilePlain
+	case strings.Contains(term, "direct") || strings.Contains(term, "truecolor"):
+		return Profile{Colors: LevelTrueColor, Options: SGROptAll}
+	case strings.Contains(term, "256color"):
+		return Profile{Colors: LevelANSI256, Options: SGROptAll}
+	}
+	return Profile{Colors: LevelANSI, Options: SGROptAll}
+}
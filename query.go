@@ -0,0 +1,264 @@
+package ansicolor
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrQueryTimeout indicates that the terminal did not answer a query before the timeout elapsed.
+// ErrQueryUnsupported indicates that the terminal answered, but not to the query that was sent.
+var (
+	ErrQueryTimeout     = errors.New("terminal query timed out")
+	ErrQueryUnsupported = errors.New("terminal query not supported")
+)
+
+// deviceAttributesQuery requests the primary device attributes (DA1). Virtually every terminal answers it, so it
+// is sent after each query as a sentinel: once its reply arrives, any reply to the preceding query has arrived too.
+const deviceAttributesQuery = "\033[c"
+
+// queryTerminal writes the query to the terminal followed by a device attributes request and collects the OSC and
+// DCS replies received until the device attributes reply arrives or the timeout elapses.
+//
+// The terminal must already be in raw (non-canonical, no echo) mode, otherwise the replies are held back by the
+// line discipline and echoed to the screen.
+func queryTerminal(tty io.ReadWriter, query string, timeout time.Duration) ([]Token, error) {
+	if _, err := io.WriteString(tty, query+deviceAttributesQuery); err != nil {
+		return nil, err
+	}
+	r := newTimeoutReader(tty, timeout)
+	defer r.close()
+
+	replies, err := scanReplies(r)
+	if errors.Is(err, ErrQueryTimeout) && len(replies) > 0 {
+		// The terminal answered the query but not the sentinel; the replies are still usable.
+		return replies, nil
+	}
+	return replies, err
+}
+
+// scanReplies reads from r until a device attributes reply is found, returning the OSC and DCS tokens seen on the
+// way. Anything else the terminal sends in the meantime is discarded.
+func scanReplies(r *timeoutReader) ([]Token, error) {
+	var replies []Token
+	done := false
+	lex := NewLexer(func(t Token) {
+		switch {
+		case t.Kind == TokenOSC || t.Kind == TokenDCS:
+			replies = append(replies, t)
+		case t.Kind == TokenCSI && t.Prefix == '?' && t.Final == 'c':
+			done = true
+		}
+	})
+	buf := make([]byte, 1)
+	for !done {
+		b, err := r.ReadByte()
+		if err != nil {
+			return replies, err
+		}
+		buf[0] = b
+		_, _ = lex.Write(buf)
+	}
+	return replies, nil
+}
+
+// deadlineSetter is implemented by readers, such as *os.File, that support read deadlines.
+type deadlineSetter interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// readResult carries the outcome of a single Read performed by a background reader goroutine.
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// ttyReaders holds the background readers of the terminals that do not support read deadlines, one per terminal,
+// so that consecutive queries on a terminal share its reader. A reader is dropped once a read fails.
+var (
+	ttyReadersMu sync.Mutex
+	ttyReaders   = map[io.Reader]*ttyReader{}
+)
+
+// ttyReader reads a terminal that does not support read deadlines on a background goroutine, one Read per request.
+// A read still in progress when a query gives up delivers its data to the next query, and the input left over by a
+// query is kept for the next one, so no reply is lost between queries.
+type ttyReader struct {
+	r        io.Reader
+	requests chan struct{}
+	results  chan readResult
+
+	mu      sync.Mutex
+	reading bool   // a read was requested and its result has not been received yet
+	unread  []byte // input received but not consumed by the previous query
+}
+
+// sharedTTYReader returns the background reader of r, starting it if needed. Readers that cannot be used as map
+// keys get a reader of their own.
+func sharedTTYReader(r io.Reader) *ttyReader {
+	if !reflect.TypeOf(r).Comparable() {
+		return newTTYReader(r, false)
+	}
+	ttyReadersMu.Lock()
+	defer ttyReadersMu.Unlock()
+	tr, ok := ttyReaders[r]
+	if !ok {
+		tr = newTTYReader(r, true)
+		ttyReaders[r] = tr
+	}
+	return tr
+}
+
+// newTTYReader creates a ttyReader for r and starts its goroutine. Shared readers remove themselves from
+// ttyReaders when a read fails.
+func newTTYReader(r io.Reader, shared bool) *ttyReader {
+	tr := &ttyReader{
+		r:        r,
+		requests: make(chan struct{}, 1),
+		results:  make(chan readResult, 1),
+	}
+	go tr.readLoop(shared)
+	return tr
+}
+
+// readLoop performs one blocking read per request until a read fails.
+func (tr *ttyReader) readLoop(shared bool) {
+	for range tr.requests {
+		buf := make([]byte, 256)
+		n, err := tr.r.Read(buf)
+		tr.results <- readResult{data: buf[:n], err: err}
+		if err != nil {
+			if shared {
+				ttyReadersMu.Lock()
+				if ttyReaders[tr.r] == tr {
+					delete(ttyReaders, tr.r)
+				}
+				ttyReadersMu.Unlock()
+			}
+			return
+		}
+	}
+}
+
+// read returns the input left over by the previous query, or the result of the next read of the terminal. It
+// returns ErrQueryTimeout if the deadline passes first; the read then stays pending for the next call.
+func (tr *ttyReader) read(deadline time.Time) ([]byte, error) {
+	tr.mu.Lock()
+	if data := tr.unread; len(data) > 0 {
+		tr.unread = nil
+		tr.mu.Unlock()
+		return data, nil
+	}
+	if !tr.reading {
+		tr.reading = true
+		tr.requests <- struct{}{}
+	}
+	tr.mu.Unlock()
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return nil, ErrQueryTimeout
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case res := <-tr.results:
+		tr.mu.Lock()
+		tr.reading = false
+		tr.mu.Unlock()
+		if len(res.data) == 0 && res.err == nil {
+			return nil, io.ErrNoProgress
+		}
+		return res.data, res.err
+	case <-timer.C:
+		return nil, ErrQueryTimeout
+	}
+}
+
+// unreadBytes puts back input that a query received but did not consume.
+func (tr *ttyReader) unreadBytes(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.unread = append(append([]byte(nil), data...), tr.unread...)
+}
+
+// timeoutReader reads bytes from a terminal, failing with ErrQueryTimeout once the deadline has passed.
+// Readers that support read deadlines are read directly; all other readers are read through their shared
+// ttyReader.
+type timeoutReader struct {
+	r        io.Reader
+	deadline time.Time
+	ds       deadlineSetter
+	tty      *ttyReader
+	buf      []byte
+	pos      int
+}
+
+// newTimeoutReader creates a timeoutReader for r that gives up after the provided timeout.
+func newTimeoutReader(r io.Reader, timeout time.Duration) *timeoutReader {
+	t := &timeoutReader{
+		r:        r,
+		deadline: time.Now().Add(timeout),
+	}
+	if ds, ok := r.(deadlineSetter); ok && ds.SetReadDeadline(t.deadline) == nil {
+		t.ds = ds
+	} else {
+		t.tty = sharedTTYReader(r)
+	}
+	return t
+}
+
+// ReadByte returns the next byte received from the terminal.
+func (t *timeoutReader) ReadByte() (byte, error) {
+	for t.pos >= len(t.buf) {
+		if err := t.fill(); err != nil {
+			return 0, err
+		}
+	}
+	b := t.buf[t.pos]
+	t.pos++
+	return b, nil
+}
+
+// fill reads the next chunk of input into the buffer.
+func (t *timeoutReader) fill() error {
+	if t.tty != nil {
+		data, err := t.tty.read(t.deadline)
+		t.buf, t.pos = data, 0
+		if len(data) > 0 {
+			return nil
+		}
+		return err
+	}
+
+	buf := make([]byte, 256)
+	n, err := t.r.Read(buf)
+	t.buf, t.pos = buf[:n], 0
+	if n > 0 {
+		return nil
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrQueryTimeout
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+	return err
+}
+
+// close releases the reader, clearing any read deadline that was set on the underlying terminal and keeping the
+// unconsumed input for the next query.
+func (t *timeoutReader) close() {
+	if t.ds != nil {
+		_ = t.ds.SetReadDeadline(time.Time{})
+	}
+	if t.tty != nil {
+		t.tty.unreadBytes(t.buf[t.pos:])
+	}
+}
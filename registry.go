@@ -0,0 +1,160 @@
+package ansicolor
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// StyleRegistry holds named Formats, like a style sheet, so that an application refers to styles by their role
+// ("error", "muted") and can restyle every use of a role in one place.
+//
+// Names are dotted paths and styles inherit from their parents: "error.title" is displayed with the Format of
+// "error" with the Format registered as "error.title" applied on top of it, and a name that is not registered
+// resolves to its closest registered parent. Colors of a child replace those of its parent while options are
+// combined.
+//
+// Lookups are lock-free and safe for concurrent use with updates: every update installs a new, fully resolved
+// snapshot of the registry. The zero value is an empty registry ready to use.
+type StyleRegistry struct {
+	mu    sync.Mutex // serializes updates
+	sheet atomic.Pointer[styleSheet]
+}
+
+// styleSheet is an immutable snapshot of a StyleRegistry.
+type styleSheet struct {
+	defined  map[string]*Format // Formats as registered
+	resolved map[string]*Format // Formats of the registered names with inheritance applied
+}
+
+// DefaultStyles is the StyleRegistry used by DefineStyle and RenderStyle.
+var DefaultStyles = NewStyleRegistry(nil)
+
+// NewStyleRegistry creates a StyleRegistry holding the provided styles.
+func NewStyleRegistry(styles map[string]*Format) *StyleRegistry {
+	r := &StyleRegistry{}
+	r.Replace(styles)
+	return r
+}
+
+// DefineStyle registers a style in DefaultStyles.
+func DefineStyle(name string, f *Format) {
+	DefaultStyles.Define(name, f)
+}
+
+// RenderStyle returns s displayed with the style of DefaultStyles with the provided name, for the global Profile.
+func RenderStyle(name, s string) string {
+	return DefaultStyles.Render(name, s)
+}
+
+// Define registers the Format of a style, replacing any Format registered with the same name. A nil Format
+// registers a style without attributes of its own, which inherits those of its parents.
+func (r *StyleRegistry) Define(name string, f *Format) {
+	r.update(func(defined map[string]*Format) {
+		if f == nil {
+			f = NewFormat()
+		}
+		defined[name] = f
+	})
+}
+
+// Remove removes a style from the registry. The styles inheriting from it are resolved again without it.
+func (r *StyleRegistry) Remove(name string) {
+	r.update(func(defined map[string]*Format) {
+		delete(defined, name)
+	})
+}
+
+// Replace replaces every style of the registry at once, e.g. to switch to another theme.
+func (r *StyleRegistry) Replace(styles map[string]*Format) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defined := make(map[string]*Format, len(styles))
+	for name, f := range styles {
+		if f == nil {
+			f = NewFormat()
+		}
+		defined[name] = f
+	}
+	r.sheet.Store(newStyleSheet(defined))
+}
+
+// Style returns the Format of a style with inheritance applied. The boolean result reports whether the name or
+// one of its parents is registered; if not, a Format without attributes is returned.
+func (r *StyleRegistry) Style(name string) (*Format, bool) {
+	resolved := r.load().resolved
+	for {
+		if f, ok := resolved[name]; ok {
+			return f, true
+		}
+		dot := strings.LastIndexByte(name, '.')
+		if dot < 0 {
+			return NewFormat(), false
+		}
+		name = name[:dot]
+	}
+}
+
+// Names returns the registered style names in sorted order.
+func (r *StyleRegistry) Names() []string {
+	defined := r.load().defined
+	names := make([]string, 0, len(defined))
+	for name := range defined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render returns s displayed with the style with the provided name, for the global Profile. The style is looked
+// up on every call, so the output follows changes to the registry.
+func (r *StyleRegistry) Render(name, s string) string {
+	f, _ := r.Style(name)
+	return renderStyled(f, s, GetProfile())
+}
+
+// update applies a change to a copy of the registered styles and installs the result.
+func (r *StyleRegistry) update(change func(defined map[string]*Format)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.load().defined
+	defined := make(map[string]*Format, len(old)+1)
+	for name, f := range old {
+		defined[name] = f
+	}
+	change(defined)
+	r.sheet.Store(newStyleSheet(defined))
+}
+
+// load returns the current snapshot of the registry.
+func (r *StyleRegistry) load() *styleSheet {
+	if sheet := r.sheet.Load(); sheet != nil {
+		return sheet
+	}
+	return &styleSheet{}
+}
+
+// newStyleSheet resolves the inheritance of registered styles, parents before their children.
+func newStyleSheet(defined map[string]*Format) *styleSheet {
+	names := make([]string, 0, len(defined))
+	for name := range defined {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.Count(names[i], ".") < strings.Count(names[j], ".")
+	})
+	resolved := make(map[string]*Format, len(defined))
+	for _, name := range names {
+		parent := NewFormat()
+		for p := name; strings.Contains(p, "."); {
+			p = p[:strings.LastIndexByte(p, '.')]
+			if f, ok := resolved[p]; ok {
+				parent = f
+				break
+			}
+		}
+		resolved[name] = parent.overlay(defined[name])
+	}
+	return &styleSheet{defined: defined, resolved: resolved}
+}
@@ -0,0 +1,137 @@
+package ansicolor
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStyleRegistryInheritance(t *testing.T) {
+	bold := NewFormat().WithOption(SGROptBold)
+	r := NewStyleRegistry(map[string]*Format{
+		"error":       bold.WithForeground(FgRed),
+		"error.title": NewFormat().WithOption(SGROptUnderline),
+		"error.hint":  NewFormat().WithForeground(FgBlue),
+		"error.quiet": nil,
+		"a":           NewFormat().WithOption(SGROptItalic),
+		"a.b.c":       NewFormat().WithForeground(FgGreen),
+	})
+	tests := []struct {
+		name  string
+		want  *Format
+		found bool
+	}{
+		{name: "error", want: bold.WithForeground(FgRed), found: true},
+		// Options are combined and colors of the child replace those of the parent.
+		{name: "error.title", want: bold.WithForeground(FgRed).WithOption(SGROptUnderline), found: true},
+		{name: "error.hint", want: bold.WithForeground(FgBlue), found: true},
+		{name: "error.quiet", want: bold.WithForeground(FgRed), found: true},
+		// Names that are not registered resolve to their closest registered parent.
+		{name: "error.title.extra", want: bold.WithForeground(FgRed).WithOption(SGROptUnderline), found: true},
+		{name: "error.other", want: bold.WithForeground(FgRed), found: true},
+		{name: "a.b", want: NewFormat().WithOption(SGROptItalic), found: true},
+		{name: "a.b.c", want: NewFormat().WithOption(SGROptItalic).WithForeground(FgGreen), found: true},
+		{name: "a.b.c.d", want: NewFormat().WithOption(SGROptItalic).WithForeground(FgGreen), found: true},
+		{name: "errors", want: NewFormat()},
+		{name: "", want: NewFormat()},
+	}
+	for _, tt := range tests {
+		got, found := r.Style(tt.name)
+		if !got.Equal(tt.want) || found != tt.found {
+			t.Errorf("Style(%q) = %q, %v, want %q, %v", tt.name, got.String(), found, tt.want.String(), tt.found)
+		}
+	}
+	want := []string{"a", "a.b.c", "error", "error.hint", "error.quiet", "error.title"}
+	if got := r.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %q, want %q", got, want)
+	}
+}
+
+func TestStyleRegistryUpdate(t *testing.T) {
+	var r StyleRegistry
+	if f, found := r.Style("error"); found || !f.IsZero() {
+		t.Errorf("zero registry: Style() = %q, %v", f.String(), found)
+	}
+	red := NewFormat().WithForeground(FgRed)
+	underline := NewFormat().WithOption(SGROptUnderline)
+	r.Define("error.title", underline)
+	r.Define("error", red)
+	check := func(step, name string, want *Format, wantFound bool) {
+		t.Helper()
+		if got, found := r.Style(name); !got.Equal(want) || found != wantFound {
+			t.Errorf("%s: Style(%q) = %q, %v, want %q, %v", step, name, got.String(), found, want.String(), wantFound)
+		}
+	}
+	// Children are resolved again when a parent is defined, redefined or removed.
+	check("parent defined", "error.title", red.WithOption(SGROptUnderline), true)
+	r.Define("error", NewFormat().WithForeground(FgGreen))
+	check("parent redefined", "error.title", underline.WithForeground(FgGreen), true)
+	r.Remove("error")
+	check("parent removed", "error.title", underline, true)
+	check("parent removed", "error", NewFormat(), false)
+	r.Define("error", red)
+	r.Remove("error.title")
+	check("child removed", "error.title", red, true)
+	r.Remove("missing")
+	check("missing removed", "error", red, true)
+
+	r.Replace(map[string]*Format{"muted": NewFormat().WithOption(SGROptFaint)})
+	check("replaced", "error.title", NewFormat(), false)
+	check("replaced", "muted", NewFormat().WithOption(SGROptFaint), true)
+}
+
+func TestStyleRegistryRender(t *testing.T) {
+	defer SetProfile(GetProfile())
+	SetProfile(ProfileTrueColor)
+	r := NewStyleRegistry(map[string]*Format{"error": NewFormat().WithOption(SGROptBold)})
+	if got, want := r.Render("error.title", "x"), "\033[1mx\033[0m"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if got := r.Render("other", "x"); got != "x" {
+		t.Errorf("Render() of an unknown style = %q, want %q", got, "x")
+	}
+	SetProfile(ProfilePlain)
+	if got := r.Render("error", "x"); got != "x" {
+		t.Errorf("Render() with a plain profile = %q, want %q", got, "x")
+	}
+}
+
+// TestStyleRegistryConcurrent renders styles while they are redefined; run with -race.
+func TestStyleRegistryConcurrent(t *testing.T) {
+	defer SetProfile(GetProfile())
+	SetProfile(ProfileTrueColor)
+	red, blue := NewFormat().WithForeground(FgRed), NewFormat().WithForeground(FgBlue)
+	r := NewStyleRegistry(map[string]*Format{"status": red})
+	valid := map[string]bool{"\033[31mx\033[0m": true, "\033[34mx\033[0m": true}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if got := r.Render("status.ok", "x"); !valid[got] {
+					t.Errorf("Render() = %q during Define", got)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		if i%2 == 0 {
+			r.Define("status", blue)
+		} else {
+			r.Define("status", red)
+		}
+		r.Define("status.ok", nil)
+		_ = r.Names()
+	}
+	close(done)
+	wg.Wait()
+}
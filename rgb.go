@@ -0,0 +1,109 @@
+package ansicolor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidColorSpec indicates that a color specification could not be parsed.
+var ErrInvalidColorSpec = errors.New("invalid color specification")
+
+// RGB represents a 24-bit color value with 8 bits per channel.
+type RGB struct {
+	R, G, B uint8
+}
+
+// NewRGB creates a new RGB value from the provided red, green and blue channels.
+func NewRGB(r, g, b uint8) RGB {
+	return RGB{R: r, G: g, B: b}
+}
+
+// ParseXColor parses an X11 color specification such as the ones terminals send in reply to OSC color queries.
+// Both the rgb:r/g/b form (1-4 hex digits per channel) and the #rgb, #rrggbb, #rrrgggbbb and #rrrrggggbbbb
+// forms are accepted. Returns ErrInvalidColorSpec if the specification is malformed.
+func ParseXColor(spec string) (RGB, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case strings.HasPrefix(spec, "rgb:"):
+		parts := strings.Split(spec[len("rgb:"):], "/")
+		if len(parts) != 3 {
+			return RGB{}, ErrInvalidColorSpec
+		}
+		var ch [3]uint8
+		for i, p := range parts {
+			v, err := scaleHexChannel(p)
+			if err != nil {
+				return RGB{}, err
+			}
+			ch[i] = v
+		}
+		return RGB{R: ch[0], G: ch[1], B: ch[2]}, nil
+	case strings.HasPrefix(spec, "#"):
+		digits := spec[1:]
+		if len(digits) == 0 || len(digits)%3 != 0 || len(digits) > 12 {
+			return RGB{}, ErrInvalidColorSpec
+		}
+		n := len(digits) / 3
+		var ch [3]uint8
+		for i := range ch {
+			v, err := scaleHexChannel(digits[i*n : (i+1)*n])
+			if err != nil {
+				return RGB{}, err
+			}
+			ch[i] = v
+		}
+		return RGB{R: ch[0], G: ch[1], B: ch[2]}, nil
+	}
+	return RGB{}, ErrInvalidColorSpec
+}
+
+// scaleHexChannel parses a 1-4 digit hexadecimal channel value and scales it to the 0-255 range.
+func scaleHexChannel(s string) (uint8, error) {
+	if len(s) == 0 || len(s) > 4 {
+		return 0, ErrInvalidColorSpec
+	}
+	v, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, ErrInvalidColorSpec
+	}
+	maxVal := uint64(1)<<(4*len(s)) - 1
+	return uint8((v*255 + maxVal/2) / maxVal), nil
+}
+
+// Hex returns the color in the #rrggbb notation.
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// XColor returns the color in the rgb:rrrr/gggg/bbbb notation understood by OSC color commands.
+func (c RGB) XColor() string {
+	return fmt.Sprintf("rgb:%02x%02x/%02x%02x/%02x%02x", c.R, c.R, c.G, c.G, c.B, c.B)
+}
+
+// String returns the color in the #rrggbb notation.
+func (c RGB) String() string {
+	return c.Hex()
+}
+
+// Luminance returns the relative luminance of the color as defined by WCAG 2, in the range [0, 1].
+func (c RGB) Luminance() float64 {
+	return 0.2126*linearChannel(c.R) + 0.7152*linearChannel(c.G) + 0.0722*linearChannel(c.B)
+}
+
+// linearChannel converts an sRGB channel value to its linear light intensity.
+func linearChannel(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+// IsDark reports whether the color is dark, that is whether white text on it has more contrast than black text.
+func (c RGB) IsDark() bool {
+	// Contrast against black and white is equal at a luminance of roughly 0.179.
+	return c.Luminance() < 0.179
+}
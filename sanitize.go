@@ -0,0 +1,101 @@
+package ansicolor
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SanitizePolicy decides which escape sequences of untrusted text, such as commit messages or file names, may
+// reach the terminal. Text, newlines and tabs always pass, with invalid UTF-8, such as 8-bit C1 controls,
+// replaced by U+FFFD. Everything the policy does not allow, from clipboard writes (OSC 52) and title changes to
+// cursor movements and carriage returns, is removed or, with ShowEscapes, displayed in caret notation
+// ("^[]52;c;...^G") so that it is visible but harmless.
+type SanitizePolicy struct {
+	// AllowSGR lets colors and text styles through.
+	AllowSGR bool
+	// AllowHyperlinks lets OSC 8 hyperlinks through, provided their parameters and URI are printable: valid UTF-8
+	// without control characters.
+	AllowHyperlinks bool
+	// ShowEscapes displays disallowed sequences and control characters in caret notation instead of removing them.
+	ShowEscapes bool
+}
+
+// Sanitize makes untrusted text safe to print: SGR sequences are kept and every other escape sequence or control
+// character is displayed in caret notation.
+func Sanitize(s string) string {
+	return SanitizePolicy{AllowSGR: true, ShowEscapes: true}.Sanitize(s)
+}
+
+// Sanitize applies the policy to s. Styles and hyperlinks that the text leaves open are closed at its end, so the
+// text cannot affect anything printed after it.
+func (p SanitizePolicy) Sanitize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	style := newTextStyle()
+	for _, tok := range Tokenize(s) {
+		_, link := hyperlinkURI(tok)
+		switch {
+		case tok.Kind == TokenText:
+			// Invalid UTF-8 is replaced so that stray bytes, such as 8-bit C1 controls, cannot reach the terminal.
+			writeValidUTF8(&b, tok.Raw)
+		case keepPlain(tok):
+			b.WriteString(tok.Raw)
+		case tok.IsSGR() && p.AllowSGR:
+			// Rewritten in 7-bit form, as C1 controls would be mangled by terminals that do not support them.
+			style.update(tok)
+			b.WriteString(StartFormat + tok.Params + EndFormat)
+		case link && p.AllowHyperlinks && isPrintable(tok.Data):
+			style.update(tok)
+			b.WriteString(StartOSC + tok.Data + EndOSC)
+		case p.ShowEscapes:
+			writeCaret(&b, tok.Raw)
+		}
+	}
+	b.WriteString(style.close())
+	return b.String()
+}
+
+// writeValidUTF8 writes s with every byte that is not part of a valid UTF-8 sequence replaced by U+FFFD.
+func writeValidUTF8(b *strings.Builder, s string) {
+	for s != "" {
+		r, size := utf8.DecodeRuneInString(s)
+		if r == utf8.RuneError && size == 1 {
+			b.WriteRune(utf8.RuneError)
+		} else {
+			b.WriteString(s[:size])
+		}
+		s = s[size:]
+	}
+}
+
+// isPrintable reports whether s is valid UTF-8 without C0 controls, DEL or C1 controls, as required of the
+// parameters and URI of the hyperlinks let through.
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if r == utf8.RuneError || r < 0x20 || r >= 0x7f && r < 0xa0 {
+			return false
+		}
+	}
+	return true
+}
+
+// writeCaret writes s with its control characters in caret notation: ESC as "^[", BEL as "^G", DEL as "^?" and
+// C1 controls as their 7-bit equivalents, e.g. "^[[" for CSI.
+func writeCaret(b *strings.Builder, s string) {
+	for _, r := range s {
+		switch {
+		case r == utf8.RuneError:
+			b.WriteString("�")
+		case r < 0x20:
+			b.WriteByte('^')
+			b.WriteByte(byte(r) + 0x40)
+		case r == 0x7f:
+			b.WriteString("^?")
+		case r >= 0x80 && r < 0xa0:
+			b.WriteString("^[")
+			b.WriteByte(byte(r) - 0x40)
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
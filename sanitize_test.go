@@ -0,0 +1,50 @@
+package ansicolor
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain\ttext\n", want: "plain\ttext\n"},
+		{in: "\033[1;31mred\033[0m", want: "\033[1;31mred\033[0m"},
+		{in: "\033[1mopen", want: "\033[1mopen\033[0m"},
+		{in: "\033]52;c;aGk=\a", want: "^[]52;c;aGk=^G"},
+		{in: "a\rb\033[2J", want: "a^Mb^[[2J"},
+		{in: "\u009b2J", want: "^[[2J"},
+		// Raw 8-bit C1 controls and other invalid UTF-8 are replaced.
+		{in: "\x9b2J", want: "�2J"},
+		{in: "\x1b[0m\x9d0;title\x9c", want: "\x1b[0m�0;title�"},
+		{in: "ok\xff\xfe", want: "ok��"},
+		{in: "é\xc3", want: "é�"},
+		{in: "\033]8;;http://x\033\\link\033]8;;\033\\", want: "^[]8;;http://x^[\\link^[]8;;^[\\"},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizePolicyHyperlinks(t *testing.T) {
+	p := SanitizePolicy{AllowSGR: true, AllowHyperlinks: true}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "\033]8;;http://x\033\\link\033]8;;\a", want: "\033]8;;http://x\033\\link\033]8;;\033\\"},
+		{in: "\033]8;id=1;http://é.example\a", want: "\033]8;id=1;http://é.example\033\\\033]8;;\033\\"},
+		// Hyperlinks whose parameters or URI hold control characters are dropped.
+		{in: "\033]8;;http://x\u009b2J\033\\link", want: "link"},
+		{in: "\033]8;;http://x\x9b2J\033\\link", want: "link"},
+		{in: "\033]8;;http://x\x7f\033\\link", want: "link"},
+		{in: "\033]8;\xff;http://x\033\\link", want: "link"},
+		{in: "\033]52;c;aGk=\a\rtext", want: "text"},
+	}
+	for _, tt := range tests {
+		if got := p.Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
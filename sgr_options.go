@@ -30,6 +30,24 @@ const (
 	SGROptDoubleUnderline
 )
 
+// SGROptAll combines every SGROption.
+const SGROptAll = SGROptBold | SGROptFaint | SGROptItalic | SGROptUnderline | SGROptBlink | SGROptFastBlink |
+	SGROptReverse | SGROptConceal | SGROptStrike | SGROptDoubleUnderline
+
+// sgrOptOrder lists every SGROption in ascending order, so that options are always emitted in the same order.
+var sgrOptOrder = []SGROption{
+	SGROptBold,
+	SGROptFaint,
+	SGROptItalic,
+	SGROptUnderline,
+	SGROptBlink,
+	SGROptFastBlink,
+	SGROptReverse,
+	SGROptConceal,
+	SGROptStrike,
+	SGROptDoubleUnderline,
+}
+
 // Set updates the SGROption by enabling the specified options using a bitwise OR operation.
 func (s *SGROption) Set(options SGROption) {
 	*s |= options
@@ -60,9 +78,9 @@ func (s *SGROption) String() string {
 		return ""
 	}
 	var b strings.Builder
-	for opt, setter := range SGROptSetterLookup {
+	for _, opt := range sgrOptOrder {
 		if s.Has(opt) {
-			b.WriteString(setter.Short())
+			b.WriteString(SGROptSetterLookup[opt].Short())
 			b.WriteString(";")
 		}
 	}
@@ -78,9 +96,9 @@ func (s *SGROption) ClearString() string {
 		return ""
 	}
 	var b strings.Builder
-	for opt, clearer := range SGROptClearerLookup {
+	for _, opt := range sgrOptOrder {
 		if s.Has(opt) {
-			b.WriteString(clearer.Short())
+			b.WriteString(SGROptClearerLookup[opt].Short())
 			b.WriteString(";")
 		}
 	}
@@ -0,0 +1,244 @@
+package ansicolor
+
+import (
+	"strconv"
+	"strings"
+)
+
+// sgrReset is the SGR parameter that resets every attribute.
+// sgrFgExtended, sgrBgExtended and sgrUlExtended introduce extended foreground, background and underline colors.
+// sgrUlDefault resets the underline color.
+const (
+	sgrReset      = 0
+	sgrFgExtended = 38
+	sgrBgExtended = 48
+	sgrUlExtended = 58
+	sgrUlDefault  = 59
+)
+
+// sgrAttr is a single SGR attribute: one parameter together with the parameters it consumes, such as 38;5;208.
+type sgrAttr struct {
+	code  int    // the leading parameter, -1 if it is not a number
+	raw   string // the parameter text as written, e.g. "1", "38;5;208" or "38:2::1:2:3"
+	color *Color // the extended color of 38, 48 and 58 attributes, nil if malformed
+}
+
+// splitSGR splits the parameters of an SGR sequence (the text between "\033[" and "m") into attributes.
+// Empty parameters stand for 0, so an empty parameter string is a reset. An extended color cut short, such as
+// "38;5", takes the remaining parameters and has no color.
+func splitSGR(params string) []sgrAttr {
+	fields := strings.Split(params, ";")
+	attrs := make([]sgrAttr, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		sub := strings.Split(fields[i], ":")
+		attr := sgrAttr{code: sgrNumber(sub[0]), raw: fields[i]}
+		if attr.isExtended() {
+			if len(sub) > 1 {
+				// Colon form: 38:5:n, 38:2::r:g:b or 38:2:r:g:b
+				attr.color = parseSGRColor(sub[1:], true)
+			} else {
+				// Semicolon form: 38;5;n or 38;2;r;g;b
+				n := 0
+				if i+1 < len(fields) {
+					switch fields[i+1] {
+					case "5":
+						n = 2
+					case "2":
+						n = 4
+					}
+				}
+				switch {
+				case n > 0 && i+n < len(fields):
+					attr.color = parseSGRColor(fields[i+1:i+n+1], false)
+					attr.raw = strings.Join(fields[i:i+n+1], ";")
+					i += n
+				case n > 0:
+					// Truncated color: the remaining parameters belong to it, they are not attributes.
+					attr.raw = strings.Join(fields[i:], ";")
+					i = len(fields) - 1
+				}
+			}
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// sgrNumber parses an SGR parameter, treating an empty parameter as 0 and returning -1 for anything else
+// that is not a number.
+func sgrNumber(s string) int {
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+// parseSGRColor parses the color specification following 38, 48 or 58: "5", n or "2", [colorspace,] r, g, b.
+// The optional color space identifier is only allowed in the colon form.
+func parseSGRColor(spec []string, colon bool) *Color {
+	channel := func(s string) (uint8, bool) {
+		n := sgrNumber(s)
+		return uint8(n), n >= 0 && n <= 255
+	}
+	switch {
+	case len(spec) == 2 && spec[0] == "5":
+		if v, ok := channel(spec[1]); ok {
+			c := Color256(v)
+			return &c
+		}
+	case spec[0] == "2" && (len(spec) == 4 || colon && len(spec) == 5):
+		rgb := spec[len(spec)-3:]
+		r, okR := channel(rgb[0])
+		g, okG := channel(rgb[1])
+		b, okB := channel(rgb[2])
+		if okR && okG && okB {
+			c := TrueColor(r, g, b)
+			return &c
+		}
+	}
+	return nil
+}
+
+// sgrSetterOptions maps SGR parameters that enable an attribute to the matching SGROption.
+var sgrSetterOptions = map[int]SGROption{
+	int(SGRBold):            SGROptBold,
+	int(SGRFaint):           SGROptFaint,
+	int(SGRItalic):          SGROptItalic,
+	int(SGRUnderline):       SGROptUnderline,
+	int(SGRBlink):           SGROptBlink,
+	int(SGRFastBlink):       SGROptFastBlink,
+	int(SGRReverse):         SGROptReverse,
+	int(SGRConceal):         SGROptConceal,
+	int(SGRStrike):          SGROptStrike,
+	int(SGRDoubleUnderline): SGROptDoubleUnderline,
+}
+
+// sgrClearerOptions maps SGR parameters that disable attributes to the SGROptions they clear.
+var sgrClearerOptions = map[int]SGROption{
+	int(SGRRemoveIntensity): SGROptBold | SGROptFaint,
+	int(SGRRemoveItalic):    SGROptItalic,
+	int(SGRRemoveUnderline): SGROptUnderline | SGROptDoubleUnderline,
+	int(SGRRemoveBlink):     SGROptBlink | SGROptFastBlink,
+	int(SGRRemoveReverse):   SGROptReverse,
+	int(SGRRemoveConceal):   SGROptConceal,
+	int(SGRRemoveStrike):    SGROptStrike,
+}
+
+// ApplySGR returns a new Format describing the attributes in effect after a terminal displaying f receives an
+// SGR sequence with the provided parameters (the text between "\033[" and "m", e.g. "1;38;5;208").
+// Parameters that the Format cannot represent are ignored. A reset yields a Format without any attributes.
+func (f *Format) ApplySGR(params string) *Format {
+	nf := f.clone()
+	nf.applySGR(splitSGR(params))
+	nf.gen()
+	return nf
+}
+
+// applySGR applies SGR attributes to the Format in place. The cached string is not regenerated.
+func (f *Format) applySGR(attrs []sgrAttr) {
+	for _, attr := range attrs {
+		code := attr.code
+		switch {
+		case code == sgrReset:
+			*f = Format{}
+		case code == sgrFgExtended:
+			if attr.color != nil {
+				c := *attr.color
+				f.fg, f.fgx = nil, &c
+			}
+		case code == sgrBgExtended:
+			if attr.color != nil {
+				c := *attr.color
+				f.bg, f.bgx = nil, &c
+			}
+		case code == sgrUlExtended:
+			if attr.color != nil {
+				c := *attr.color
+				f.ulx = &c
+			}
+		case code == sgrUlDefault:
+			f.ulx = nil
+		case code == int(FgDefault):
+			f.fg, f.fgx = nil, nil
+		case code == int(BgDefault):
+			f.bg, f.bgx = nil, nil
+		case FgColor(code).IsValid():
+			c := FgColor(code)
+			f.fg, f.fgx = &c, nil
+		case BgColor(code).IsValid():
+			c := BgColor(code)
+			f.bg, f.bgx = &c, nil
+		default:
+			if opt, ok := sgrSetterOptions[code]; ok {
+				f.opts.Set(opt)
+			} else if opts, ok := sgrClearerOptions[code]; ok {
+				f.opts.Clear(opts)
+			}
+		}
+	}
+}
+
+// clone returns a copy of the Format.
+func (f *Format) clone() *Format {
+	nf := *f
+	return &nf
+}
+
+// isExtended reports whether the attribute is an extended foreground, background or underline color.
+func (a sgrAttr) isExtended() bool {
+	return a.code == sgrFgExtended || a.code == sgrBgExtended || a.code == sgrUlExtended
+}
+
+// isFg reports whether the attribute sets the foreground color.
+func (a sgrAttr) isFg() bool {
+	return a.code == sgrFgExtended || a.code != int(FgDefault) && FgColor(a.code).IsValid()
+}
+
+// isBg reports whether the attribute sets the background color.
+func (a sgrAttr) isBg() bool {
+	return a.code == sgrBgExtended || a.code != int(BgDefault) && BgColor(a.code).IsValid()
+}
+
+// isColor reports whether the attribute sets or resets the foreground, background or underline color.
+func (a sgrAttr) isColor() bool {
+	return a.isFg() || a.isBg() || a.code == int(FgDefault) || a.code == int(BgDefault) ||
+		a.code == sgrUlExtended || a.code == sgrUlDefault
+}
+
+// isStyle reports whether the attribute changes a rendition other than the colors, such as bold or underline.
+func (a sgrAttr) isStyle() bool {
+	return a.code > sgrReset && !a.isColor()
+}
+
+// filterSGR rewrites the SGR sequences of s so that they only keep the attributes accepted by keep, dropping
+// malformed extended colors. Sequences left without any attribute are removed; everything else in s is copied
+// unchanged.
+func filterSGR(s string, keep func(sgrAttr) bool) string {
+	var b strings.Builder
+	for _, tok := range Tokenize(s) {
+		if !tok.IsSGR() {
+			b.WriteString(tok.Raw)
+			continue
+		}
+		var kept []string
+		for _, attr := range splitSGR(tok.Params) {
+			if attr.isExtended() && attr.color == nil {
+				// Malformed colors are dropped; written alone, their parameters could read as other attributes.
+				continue
+			}
+			if keep(attr) {
+				kept = append(kept, attr.raw)
+			}
+		}
+		if len(kept) > 0 {
+			b.WriteString(StartFormat)
+			b.WriteString(strings.Join(kept, ";"))
+			b.WriteString(EndFormat)
+		}
+	}
+	return b.String()
+}
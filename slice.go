@@ -0,0 +1,83 @@
+package ansicolor
+
+import (
+	"strings"
+)
+
+// Slice returns the part of s displayed in the terminal columns fromCol (inclusive) to toCol (exclusive),
+// counting from 0, as measured by Width. It is meant for horizontal scrolling of styled lines.
+//
+// The result starts with the style and hyperlink in effect at fromCol, so it displays like the same columns of
+// the original, and always ends with a reset, followed by the end of the hyperlink in effect at toCol if any, so
+// that it can be printed anywhere without leaking its style. It is empty, without a reset, if nothing of s falls
+// in the range. A wide character cut by either boundary is replaced by spaces for the columns it covers inside
+// the range. Escape sequences other than SGR and hyperlinks outside the range are dropped.
+func Slice(s string, fromCol, toCol int) string {
+	if fromCol < 0 {
+		fromCol = 0
+	}
+	if toCol <= fromCol {
+		return ""
+	}
+	var b strings.Builder
+	style := newTextStyle()
+	started := false
+	start := func() {
+		if !started {
+			started = true
+			b.WriteString(style.open())
+		}
+	}
+
+	col := 0
+cut:
+	for _, tok := range Tokenize(s) {
+		if col >= toCol {
+			break
+		}
+		if tok.Kind != TokenText {
+			style.update(tok)
+			if _, link := hyperlinkURI(tok); !started && (tok.IsSGR() || link) {
+				// Opened along with the rest of the style once the range starts.
+				continue
+			}
+			if col >= fromCol {
+				start()
+				b.WriteString(tok.Raw)
+			}
+			continue
+		}
+		for text := tok.Raw; text != ""; {
+			cluster, cw := nextGrapheme(text)
+			text = text[len(cluster):]
+			switch {
+			case col+cw <= fromCol:
+				// Entirely before the range.
+			case col < fromCol:
+				// A wide character cut by the start of the range.
+				start()
+				b.WriteString(strings.Repeat(" ", min(col+cw, toCol)-fromCol))
+			case col+cw > toCol:
+				// A wide character cut by the end of the range.
+				start()
+				b.WriteString(strings.Repeat(" ", toCol-col))
+				break cut
+			default:
+				start()
+				b.WriteString(cluster)
+			}
+			col += cw
+			if col >= toCol {
+				break cut
+			}
+		}
+	}
+	if !started {
+		return ""
+	}
+	b.WriteString(ClearString)
+	if style.link != "" {
+		b.WriteString(closeHyperlink)
+	}
+	return b.String()
+}
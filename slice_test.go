@@ -0,0 +1,25 @@
+package ansicolor
+
+import "testing"
+
+func TestSlice(t *testing.T) {
+	tests := []struct {
+		s        string
+		from, to int
+		want     string
+	}{
+		{s: "abcdef", from: 1, to: 3, want: "bc\033[0m"},
+		{s: "abc", from: 5, to: 8, want: ""},
+		{s: "abc", from: 2, to: 2, want: ""},
+		{s: "\033[1mbold\033[0m plain", from: 2, to: 7, want: "\033[1mld\033[0m pl\033[0m"},
+		{s: "\033[31mred", from: 0, to: 2, want: "\033[31mre\033[0m"},
+		{s: "日本語", from: 1, to: 5, want: " 本 \033[0m"},
+		{s: "\033]8;;http://x\033\\link\033]8;;\033\\", from: 1, to: 3,
+			want: "\033]8;;http://x\033\\in\033[0m\033]8;;\033\\"},
+	}
+	for _, tt := range tests {
+		if got := Slice(tt.s, tt.from, tt.to); got != tt.want {
+			t.Errorf("Slice(%q, %d, %d) = %q, want %q", tt.s, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
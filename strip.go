@@ -0,0 +1,63 @@
+package ansicolor
+
+import (
+	"strings"
+)
+
+// Strip removes every escape sequence from s. Text and control characters outside escape sequences, such as
+// carriage returns, are kept unchanged.
+func Strip(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, tok := range Tokenize(s) {
+		if tok.Kind == TokenText || tok.Kind == TokenControl {
+			b.WriteString(tok.Raw)
+		}
+	}
+	return b.String()
+}
+
+// StripControls removes every escape sequence except SGR sequences from s, along with all control characters
+// except newline and tab. Colors and styles are preserved while cursor movements, hyperlinks, title changes
+// and the like are removed.
+func StripControls(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, tok := range Tokenize(s) {
+		switch {
+		case tok.IsSGR():
+			// Rebuilt from the parameters, leaving out any control character inside the sequence.
+			b.WriteString(StartFormat + tok.Params + EndFormat)
+		case keepPlain(tok):
+			b.WriteString(tok.Raw)
+		}
+	}
+	return b.String()
+}
+
+// StripColors removes the color attributes (standard, 256-color and RGB foreground, background and underline
+// colors) from the SGR sequences of s, keeping styles such as bold and every other part of s.
+func StripColors(s string) string {
+	return filterSGR(s, func(a sgrAttr) bool {
+		return !a.isColor()
+	})
+}
+
+// StripStyles removes the style attributes (bold, italic, underline and other renditions) from the SGR
+// sequences of s, keeping colors, resets and every other part of s.
+func StripStyles(s string) string {
+	return filterSGR(s, func(a sgrAttr) bool {
+		return !a.isStyle()
+	})
+}
+
+// keepPlain reports whether a token is part of the plain text of its input: text, newlines and tabs.
+func keepPlain(tok Token) bool {
+	switch tok.Kind {
+	case TokenText:
+		return true
+	case TokenControl:
+		return tok.Final == '\n' || tok.Final == '\t'
+	}
+	return false
+}
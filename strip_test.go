@@ -0,0 +1,96 @@
+package ansicolor
+
+import "testing"
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "\033[1;31merror\033[0m: failed", want: "error: failed"},
+		{in: "a\r\nb\tc\bd\a", want: "a\r\nb\tc\bd\a"},
+		{in: "\033]8;;https://example.com\033\\docs\033]8;;\a", want: "docs"},
+		{in: "\033[2J\033[1;1H\033Ptmux;x\033\\\033(Bok", want: "ok"},
+		{in: "\u009b31mx\u009b0m", want: "x"},
+		{in: "cut\033[31", want: "cut"},
+	}
+	for _, tt := range tests {
+		if got := Strip(tt.in); got != tt.want {
+			t.Errorf("Strip(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripControls(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "\033[1;31merror\033[0m", want: "\033[1;31merror\033[0m"},
+		{in: "\033]8;;https://example.com\033\\docs\033]8;;\033\\ \033[2Kx\r\n", want: "docs x\n"},
+		{in: "\033[1\a;31mx", want: "\033[1;31mx"},
+	}
+	for _, tt := range tests {
+		if got := StripControls(tt.in); got != tt.want {
+			t.Errorf("StripControls(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripSGRAttributes(t *testing.T) {
+	tests := []struct {
+		in                     string
+		colors, styles, fg, bg string
+	}{
+		{
+			in:     "\033[1;31;44mx\033[0m",
+			colors: "\033[1mx\033[0m", styles: "\033[31;44mx\033[0m",
+			fg: "\033[1;44mx\033[0m", bg: "\033[1;31mx\033[0m",
+		},
+		{
+			in:     "\033[1;38;5;208;48;2;1;2;3;4m",
+			colors: "\033[1;4m", styles: "\033[38;5;208;48;2;1;2;3m",
+			fg: "\033[1;48;2;1;2;3;4m", bg: "\033[1;38;5;208;4m",
+		},
+		{
+			in:     "\033[4;58:2::255:0:0;39;49mx",
+			colors: "\033[4mx", styles: "\033[58:2::255:0:0;39;49mx",
+			fg: "\033[4;58:2::255:0:0;39;49mx", bg: "\033[4;58:2::255:0:0;39;49mx",
+		},
+		// Truncated colors are dropped along with the parameters they hold, which must not read as blink (5).
+		{
+			in:     "a\r\n\033[1;38;5mb",
+			colors: "a\r\n\033[1mb", styles: "a\r\nb",
+			fg: "a\r\n\033[1mb", bg: "a\r\n\033[1mb",
+		},
+		{in: "\033[31;38;5m", colors: "", styles: "\033[31m", fg: "", bg: "\033[31m"},
+		{in: "\033[1;38;2;1;2mx", colors: "\033[1mx", styles: "x", fg: "\033[1mx", bg: "\033[1mx"},
+		{in: "\033[4;48:5mx", colors: "\033[4mx", styles: "x", fg: "\033[4mx", bg: "\033[4mx"},
+		{in: "\033]8;;u\033\\\033[2Jx", colors: "\033]8;;u\033\\\033[2Jx", styles: "\033]8;;u\033\\\033[2Jx",
+			fg: "\033]8;;u\033\\\033[2Jx", bg: "\033]8;;u\033\\\033[2Jx"},
+	}
+	for _, tt := range tests {
+		if got := StripColors(tt.in); got != tt.colors {
+			t.Errorf("StripColors(%q) = %q, want %q", tt.in, got, tt.colors)
+		}
+		if got := StripStyles(tt.in); got != tt.styles {
+			t.Errorf("StripStyles(%q) = %q, want %q", tt.in, got, tt.styles)
+		}
+		if got := ClearFgColor(tt.in); got != tt.fg {
+			t.Errorf("ClearFgColor(%q) = %q, want %q", tt.in, got, tt.fg)
+		}
+		if got := ClearBgColor(tt.in); got != tt.bg {
+			t.Errorf("ClearBgColor(%q) = %q, want %q", tt.in, got, tt.bg)
+		}
+	}
+}
+
+func TestApplySGRTruncatedColor(t *testing.T) {
+	for _, params := range []string{"38;5", "48;2;1;2", "1;38;5", "38"} {
+		got := NewFormat().WithForeground(FgRed).ApplySGR(params)
+		if got.HasAnyOption(SGROptBlink|SGROptFastBlink) || got.HasOption(SGROptUnderline) {
+			t.Errorf("ApplySGR(%q) = %v, reads the color parameters as options", params, got)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package ansicolor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidStyle indicates that a style specification contains a word that is not a color or option name.
+var ErrInvalidStyle = errors.New("invalid style")
+
+// ParseStyle parses a style specification such as "bold red", "underline bright white on blue" or
+// "italic #ff8800 on 236" into a Format.
+//
+// The specification is a list of words, matched case-insensitively:
+//   - option names from SGRSetterLookup, such as "bold" or "double underline",
+//   - foreground color names from FgColorLookup, such as "red" or "bright red",
+//   - "on" followed by a background color, named like a foreground color ("bright red" or "bright_red"),
+//   - "ul" followed by an underline color, named like a background color,
+//   - "default" for the terminal's default color,
+//   - "#rgb" or "#rrggbb" for an RGB color and a number from 0 to 255 for a 256-color palette index.
+//
+// An empty specification yields a Format without any attributes.
+func ParseStyle(spec string) (*Format, error) {
+	words := strings.Fields(strings.ToLower(spec))
+	f := NewFormat()
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		keyword := ""
+		if word == "on" || word == "ul" {
+			keyword = word
+			if i+1 == len(words) {
+				return nil, fmt.Errorf("%w: missing color after %q", ErrInvalidStyle, word)
+			}
+			i++
+			word = words[i]
+		}
+		next := ""
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+		base := f
+		if keyword == "ul" {
+			// Parse the underline color as a background color and move it over.
+			base = NewFormat()
+		}
+		nf, used, ok := applyStyleWord(base, word, next, keyword != "")
+		switch {
+		case !ok && keyword == "on":
+			return nil, fmt.Errorf("%w: unknown background color %q", ErrInvalidStyle, word)
+		case !ok && keyword == "ul":
+			return nil, fmt.Errorf("%w: unknown underline color %q", ErrInvalidStyle, word)
+		case !ok:
+			return nil, fmt.Errorf("%w: unknown style %q", ErrInvalidStyle, word)
+		case keyword == "ul":
+			nf = f.withUnderline(backgroundAsColor(nf))
+		}
+		f = nf
+		i += used - 1
+	}
+	return f, nil
+}
+
+// backgroundAsColor returns the background color of f as a Color, or nil for the default color.
+func backgroundAsColor(f *Format) *Color {
+	switch {
+	case f.bgx != nil:
+		return f.bgx
+	case f.bg != nil && *f.bg != BgDefault:
+		c := Color256(uint8(f.bg.PaletteIndex()))
+		return &c
+	}
+	return nil
+}
+
+// applyStyleWord applies the style named by word, or by word and next together for two-word names, to f. It
+// returns the new Format and the number of words used, or false if the words do not name a style. Only colors are
+// accepted when background is set.
+func applyStyleWord(f *Format, word, next string, background bool) (*Format, int, bool) {
+	if next != "" {
+		if nf, ok := applyStyleName(f, word+" "+next, background); ok {
+			return nf, 2, true
+		}
+	}
+	if nf, ok := applyStyleName(f, word, background); ok {
+		return nf, 1, true
+	}
+	return nil, 0, false
+}
+
+// applyStyleName applies a single style name to f.
+func applyStyleName(f *Format, name string, background bool) (*Format, bool) {
+	name = strings.ReplaceAll(name, "_", " ")
+	if c, ok := parseStyleColor(name); ok {
+		if background {
+			return f.WithBackgroundColor(c), true
+		}
+		return f.WithForegroundColor(c), true
+	}
+	if background {
+		if name == "default" {
+			return f.WithBackground(BgDefault), true
+		}
+		c, ok := BgColorLookup[strings.ReplaceAll(name, " ", "_")]
+		if !ok {
+			return nil, false
+		}
+		return f.WithBackground(c), true
+	}
+	if name == "default" {
+		return f.WithForeground(FgDefault), true
+	}
+	if c, ok := FgColorLookup[name]; ok {
+		return f.WithForeground(c), true
+	}
+	if sgr, ok := SGRSetterLookup[name]; ok {
+		return f.WithOption(sgrSetterOptions[int(sgr)]), true
+	}
+	return nil, false
+}
+
+// parseStyleColor parses an RGB color ("#rgb" or "#rrggbb") or a 256-color palette index.
+func parseStyleColor(name string) (Color, bool) {
+	if strings.HasPrefix(name, "#") && (len(name) == 4 || len(name) == 7) {
+		rgb, err := ParseXColor(name)
+		if err != nil {
+			return Color{}, false
+		}
+		return TrueColor(rgb.R, rgb.G, rgb.B), true
+	}
+	n, err := strconv.Atoi(name)
+	if err != nil || n < 0 || n > 255 || name[0] == '+' {
+		return Color{}, false
+	}
+	return Color256(uint8(n)), true
+}
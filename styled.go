@@ -0,0 +1,180 @@
+package ansicolor
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Span is a run of plain text displayed with a single Format.
+type Span struct {
+	Text   string
+	Format *Format
+}
+
+// StyledText is text made of styled spans. It keeps styling as data until the text is rendered, so it can be
+// composed, measured and split without parsing escape sequences, and rendered for any Profile or output format.
+//
+// Like Format, a StyledText is immutable: every method returns a new StyledText and leaves the receiver
+// unchanged, so values can be shared freely.
+type StyledText struct {
+	spans []Span
+}
+
+// NewStyledText creates a StyledText from spans. Spans without text are dropped, a nil Format stands for no
+// attributes and adjacent spans with equal Formats are merged.
+func NewStyledText(spans ...Span) *StyledText {
+	t := &StyledText{}
+	for _, s := range spans {
+		t.appendSpan(s)
+	}
+	return t
+}
+
+// Styled creates a StyledText holding a single span.
+func Styled(text string, f *Format) *StyledText {
+	return NewStyledText(Span{Text: text, Format: f})
+}
+
+// Append returns a new StyledText with text displayed in the provided Format added at the end.
+func (t *StyledText) Append(text string, f *Format) *StyledText {
+	nt := t.clone()
+	nt.appendSpan(Span{Text: text, Format: f})
+	return nt
+}
+
+// Concat returns a new StyledText made of t followed by each of others.
+func (t *StyledText) Concat(others ...*StyledText) *StyledText {
+	nt := t.clone()
+	for _, o := range others {
+		if o == nil {
+			continue
+		}
+		for _, s := range o.spans {
+			nt.appendSpan(s)
+		}
+	}
+	return nt
+}
+
+// Spans returns a copy of the spans making up the text.
+func (t *StyledText) Spans() []Span {
+	return append([]Span(nil), t.spans...)
+}
+
+// Len returns the number of characters (Unicode code points) in the text.
+func (t *StyledText) Len() int {
+	n := 0
+	for _, s := range t.spans {
+		n += utf8.RuneCountInString(s.Text)
+	}
+	return n
+}
+
+// Width returns the number of terminal cells needed to display the text. See Width. The text is measured as a
+// whole, so a grapheme cluster split across spans, such as a letter followed by a differently styled combining
+// mark, counts once.
+func (t *StyledText) Width() int {
+	return Width(t.String())
+}
+
+// String returns the plain text without any styling.
+func (t *StyledText) String() string {
+	var b strings.Builder
+	for _, s := range t.spans {
+		b.WriteString(s.Text)
+	}
+	return b.String()
+}
+
+// Split slices the text into all substrings separated by sep, keeping the styling of every part, and returns
+// them. It behaves like strings.Split on the plain text.
+func (t *StyledText) Split(sep string) []*StyledText {
+	plain := t.String()
+	parts := strings.Split(plain, sep)
+	out := make([]*StyledText, 0, len(parts))
+	start := 0
+	for _, part := range parts {
+		out = append(out, t.sub(start, start+len(part)))
+		start += len(part) + len(sep)
+	}
+	return out
+}
+
+// Lines splits the text at newlines. A carriage return before a newline is dropped.
+func (t *StyledText) Lines() []*StyledText {
+	lines := t.Split("\n")
+	for i, line := range lines {
+		if strings.HasSuffix(line.String(), "\r") {
+			lines[i] = line.sub(0, len(line.String())-1)
+		}
+	}
+	return lines
+}
+
+// Map returns a new StyledText in which the Format of every span is replaced by the result of fn, for example to
+// remap colors to another theme. A nil result stands for no attributes.
+func (t *StyledText) Map(fn func(*Format) *Format) *StyledText {
+	nt := &StyledText{}
+	for _, s := range t.spans {
+		nt.appendSpan(Span{Text: s.Text, Format: fn(s.Format)})
+	}
+	return nt
+}
+
+// Render returns the text with the escape sequences needed to display it on a terminal with the provided
+// Profile. Only the attributes that change between spans are emitted and the output ends with a reset if any
+// attribute is left set. A plain profile yields the plain text.
+func (t *StyledText) Render(p Profile) string {
+	var b strings.Builder
+	cur, styled := NewFormat(), false
+	for _, s := range t.spans {
+		if params := sgrTransition(cur, s.Format, p); params != "" && !cur.Equal(s.Format) {
+			b.WriteString(StartFormat)
+			b.WriteString(params)
+			b.WriteString(EndFormat)
+			styled = true
+		}
+		cur = s.Format
+		b.WriteString(s.Text)
+	}
+	if styled && !cur.IsZero() {
+		b.WriteString(ClearString)
+	}
+	return b.String()
+}
+
+// sub returns the part of the text between the byte offsets start and end of the plain text.
+func (t *StyledText) sub(start, end int) *StyledText {
+	nt := &StyledText{}
+	pos := 0
+	for _, s := range t.spans {
+		lo, hi := pos, pos+len(s.Text)
+		pos = hi
+		if hi <= start || lo >= end {
+			continue
+		}
+		from, to := max(start, lo)-lo, min(end, hi)-lo
+		nt.appendSpan(Span{Text: s.Text[from:to], Format: s.Format})
+	}
+	return nt
+}
+
+// clone returns a copy of the StyledText that can be appended to without affecting t.
+func (t *StyledText) clone() *StyledText {
+	return &StyledText{spans: t.Spans()}
+}
+
+// appendSpan adds a span in place, merging it with the last span if both have the same Format.
+func (t *StyledText) appendSpan(s Span) {
+	if s.Text == "" {
+		return
+	}
+	if s.Format == nil {
+		s.Format = NewFormat()
+	}
+	if n := len(t.spans); n > 0 && t.spans[n-1].Format.Equal(s.Format) {
+		t.spans[n-1].Text += s.Text
+		return
+	}
+	t.spans = append(t.spans, s)
+}
@@ -0,0 +1,30 @@
+package ansicolor
+
+import "testing"
+
+func TestStyledTextWidth(t *testing.T) {
+	bold := NewFormat().WithOption(SGROptBold)
+	red := NewFormat().WithForeground(FgRed)
+	tests := []struct {
+		name string
+		text *StyledText
+		want int
+	}{
+		{name: "empty", text: NewStyledText(), want: 0},
+		{name: "spans", text: Styled("ab", bold).Append("日本", red), want: 6},
+		// Grapheme clusters split across spans are measured once.
+		{name: "combining mark", text: Styled("e", bold).Append("\u0301", red), want: 1},
+		{name: "zwj sequence", text: Styled("👨", bold).Append("\u200d👩", red).Append("\u200d👧", nil), want: 2},
+		{name: "skin tone", text: Styled("👍", bold).Append("\U0001f3fd", red), want: 2},
+		{name: "flag", text: Styled("\U0001f1ef", bold).Append("\U0001f1f5", red), want: 2},
+		{name: "variation selector", text: Styled("☺", bold).Append("\ufe0f", red), want: 2},
+	}
+	for _, tt := range tests {
+		if got := tt.text.Width(); got != tt.want {
+			t.Errorf("%s: Width() = %d, want %d", tt.name, got, tt.want)
+		}
+		if got := Width(tt.text.String()); got != tt.want {
+			t.Errorf("%s: Width(String()) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
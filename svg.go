@@ -0,0 +1,266 @@
+package ansicolor
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Default settings of an SVGRenderer.
+const (
+	defaultSVGFontFamily = "ui-monospace, SFMono-Regular, Menlo, Consolas, monospace"
+	defaultSVGFontSize   = 14
+	svgTabWidth          = 8
+)
+
+// Geometry of the SVG grid, relative to the font size.
+const (
+	svgCellWidth   = 0.6  // width of a terminal cell
+	svgLineHeight  = 1.2  // height of a line
+	svgBaseline    = 0.9  // distance from the top of a line to the text baseline
+	svgUnderline   = 0.08 // distance from the baseline to the underline
+	svgUnderline2  = 0.2  // distance from the baseline to the second line of a double underline
+	svgStrike      = 0.3  // distance from the strike-through line up to the baseline
+	svgStroke      = 0.07 // thickness of underlines and strike-through lines
+	svgTitleHeight = 2.4  // height of the title bar of the window frame
+	svgButtonSize  = 0.45 // radius of the buttons in the title bar
+	svgCorner      = 0.5  // corner radius of the window frame
+)
+
+// svgButtonColors are the colors of the close, minimize and zoom buttons of the window frame.
+var svgButtonColors = [3]string{"#ff5f56", "#ffbd2e", "#27c93f"}
+
+// SVGRenderer draws text containing escape sequences as an SVG image of a terminal, e.g. for screenshots in
+// documentation. Every grapheme cluster is positioned at the start of its cell on a monospace grid, so wide
+// characters take two cells and the layout does not depend on the fonts available where it is rendered. Invalid
+// UTF-8 is replaced with U+FFFD and control characters are dropped. Colors, reverse video, bold, faint, italic,
+// underline, double underline, underline colors, strike-through and conceal are drawn; other escape sequences
+// are ignored.
+//
+// The output is deterministic: the same input and settings always produce the same SVG.
+type SVGRenderer struct {
+	// Palette defines the RGB values of the palette colors and the default colors. DefaultPalette is used if nil.
+	Palette *Palette
+	// FontFamily is the CSS font family of the text; a list of common monospace fonts if empty.
+	FontFamily string
+	// FontSize is the font size in pixels; 14 if zero. Every other dimension is derived from it.
+	FontSize float64
+	// Columns is the minimum width of the grid in cells. The grid is widened to fit the longest line.
+	Columns int
+	// Window draws a window frame with a title bar around the terminal.
+	Window bool
+	// Title is displayed in the title bar of the window frame.
+	Title string
+}
+
+// ToSVG renders s as an SVG image with the default settings. See SVGRenderer.
+func ToSVG(s string) string {
+	return SVGRenderer{}.Render(s)
+}
+
+// svgCluster is a grapheme cluster placed on the grid.
+type svgCluster struct {
+	text string
+	col  int
+}
+
+// svgRun is a run of text on the grid displayed with a single Format.
+type svgRun struct {
+	clusters []svgCluster // without the blank ones
+	col      int
+	cells    int
+	format   *Format
+}
+
+// Render renders s as an SVG document.
+func (r SVGRenderer) Render(s string) string {
+	pal, fs := r.palette(), r.fontSize()
+	cellW, lineH := fs*svgCellWidth, fs*svgLineHeight
+
+	lines := Decode(s).Lines()
+	if n := len(lines); n > 1 && lines[n-1].Len() == 0 {
+		// A trailing newline does not start a line of its own.
+		lines = lines[:n-1]
+	}
+	rows := make([][]svgRun, len(lines))
+	cols := r.Columns
+	for i, line := range lines {
+		rows[i] = svgLayout(line)
+		if n := len(rows[i]); n > 0 {
+			cols = max(cols, rows[i][n-1].col+rows[i][n-1].cells)
+		}
+	}
+
+	pad, top := fs, fs
+	if r.Window {
+		top = fs * svgTitleHeight
+	}
+	width := 2*pad + float64(cols)*cellW
+	height := top + pad + float64(len(rows))*lineH
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s"`,
+		svgNum(width), svgNum(height))
+	fmt.Fprintf(&b, ` font-family="%s" font-size="%s">`+"\n", html.EscapeString(r.fontFamily()), svgNum(fs))
+	if r.Window {
+		fmt.Fprintf(&b, `<rect width="%s" height="%s" rx="%s" fill="%s"/>`+"\n",
+			svgNum(width), svgNum(height), svgNum(fs*svgCorner), pal.Background.Hex())
+		for i, color := range svgButtonColors {
+			fmt.Fprintf(&b, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n",
+				svgNum(pad+float64(i)*fs*1.4+fs*svgButtonSize), svgNum(top/2), svgNum(fs*svgButtonSize), color)
+		}
+		if r.Title != "" {
+			fmt.Fprintf(&b, `<text x="%s" y="%s" fill="%s" text-anchor="middle" opacity="0.7">%s</text>`+"\n",
+				svgNum(width/2), svgNum(top/2+fs*0.35), pal.Foreground.Hex(), html.EscapeString(svgClean(r.Title)))
+		}
+	} else {
+		fmt.Fprintf(&b, `<rect width="%s" height="%s" fill="%s"/>`+"\n", svgNum(width), svgNum(height),
+			pal.Background.Hex())
+	}
+
+	var bgs, fgs strings.Builder
+	for row, runs := range rows {
+		y := top + float64(row)*lineH
+		baseline := y + fs*svgBaseline
+		for _, run := range runs {
+			x, w := pad+float64(run.col)*cellW, float64(run.cells)*cellW
+			fg, bg, _, bgSet := pal.FormatColors(run.format)
+			if bgSet {
+				fmt.Fprintf(&bgs, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+					svgNum(x), svgNum(y), svgNum(w), svgNum(lineH), bg.Hex())
+			}
+			if run.format.HasOption(SGROptConceal) {
+				continue
+			}
+			if len(run.clusters) > 0 {
+				fgs.WriteString(svgText(run, pad, cellW, baseline, fg))
+			}
+			ul := fg
+			if c, ok := run.format.UnderlineColor(); ok {
+				ul = pal.Color(c)
+			}
+			type line struct {
+				y     float64
+				color RGB
+			}
+			var lines []line
+			if run.format.HasAnyOption(SGROptUnderline | SGROptDoubleUnderline) {
+				lines = append(lines, line{baseline + fs*svgUnderline, ul})
+			}
+			if run.format.HasOption(SGROptDoubleUnderline) {
+				lines = append(lines, line{baseline + fs*svgUnderline2, ul})
+			}
+			if run.format.HasOption(SGROptStrike) {
+				lines = append(lines, line{baseline - fs*svgStrike, fg})
+			}
+			for _, l := range lines {
+				fmt.Fprintf(&fgs, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"%s/>`+"\n",
+					svgNum(x), svgNum(l.y), svgNum(w), svgNum(fs*svgStroke), l.color.Hex(), svgOpacity(run.format))
+			}
+		}
+	}
+	b.WriteString("<g>\n")
+	b.WriteString(bgs.String())
+	b.WriteString("</g>\n<g xml:space=\"preserve\">\n")
+	b.WriteString(fgs.String())
+	b.WriteString("</g>\n</svg>\n")
+	return b.String()
+}
+
+// svgLayout places the grapheme clusters of a line on the grid, expanding tabs.
+func svgLayout(line *StyledText) []svgRun {
+	var runs []svgRun
+	col := 0
+	for _, span := range line.Spans() {
+		run := svgRun{col: col, format: span.Format}
+		for s := svgClean(span.Text); s != ""; {
+			cluster, cw := nextGrapheme(s)
+			s = s[len(cluster):]
+			if cluster == "\t" {
+				cw = svgTabWidth - col%svgTabWidth
+			} else if strings.TrimSpace(cluster) != "" {
+				run.clusters = append(run.clusters, svgCluster{text: cluster, col: col})
+			}
+			col += cw
+		}
+		run.cells = col - run.col
+		if run.cells > 0 {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// svgClean replaces invalid UTF-8 with U+FFFD and drops the characters that XML does not allow, except tabs.
+func svgClean(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return r
+		case r < 0x20 || r >= 0x7f && r < 0xa0:
+			return -1
+		case r == 0xfffe || r == 0xffff:
+			return utf8.RuneError
+		}
+		return r
+	}, s)
+}
+
+// svgText returns the <text> element of a run, with each grapheme cluster positioned at the start of its cell.
+func svgText(run svgRun, pad, cellW, baseline float64, fg RGB) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<text y="%s" fill="%s"`, svgNum(baseline), fg.Hex())
+	if run.format.HasOption(SGROptBold) {
+		b.WriteString(` font-weight="bold"`)
+	}
+	if run.format.HasOption(SGROptItalic) {
+		b.WriteString(` font-style="italic"`)
+	}
+	b.WriteString(svgOpacity(run.format))
+	b.WriteString(">")
+	for _, c := range run.clusters {
+		fmt.Fprintf(&b, `<tspan x="%s">%s</tspan>`, svgNum(pad+float64(c.col)*cellW), html.EscapeString(c.text))
+	}
+	b.WriteString("</text>\n")
+	return b.String()
+}
+
+// svgOpacity returns the opacity attribute of faint text.
+func svgOpacity(f *Format) string {
+	if f.HasOption(SGROptFaint) {
+		return ` opacity="0.5"`
+	}
+	return ""
+}
+
+// svgNum formats a coordinate with at most two decimals.
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// palette returns the renderer's palette.
+func (r SVGRenderer) palette() *Palette {
+	if r.Palette != nil {
+		return r.Palette
+	}
+	return &DefaultPalette
+}
+
+// fontFamily returns the renderer's font family.
+func (r SVGRenderer) fontFamily() string {
+	if r.FontFamily != "" {
+		return r.FontFamily
+	}
+	return defaultSVGFontFamily
+}
+
+// fontSize returns the renderer's font size.
+func (r SVGRenderer) fontSize() float64 {
+	if r.FontSize > 0 {
+		return r.FontSize
+	}
+	return defaultSVGFontSize
+}
@@ -0,0 +1,117 @@
+package ansicolor
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// svgElements parses an SVG document, failing the test if it is not well-formed XML, and returns its elements.
+func svgElements(t *testing.T, doc string) []xml.StartElement {
+	t.Helper()
+	var elements []xml.StartElement
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return elements
+		}
+		if err != nil {
+			t.Fatalf("invalid XML: %v\n%s", err, doc)
+		}
+		if e, ok := tok.(xml.StartElement); ok {
+			elements = append(elements, e)
+		}
+	}
+}
+
+// svgAttr returns the value of an attribute of an element.
+func svgAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func TestToSVG(t *testing.T) {
+	in := "a\xffb \033[1;31;44mX\033[0m\t日\033[4;9mu\033[0m\n"
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="120.4" height="44.8" viewBox="0 0 120.4 44.8"` +
+		` font-family="ui-monospace, SFMono-Regular, Menlo, Consolas, monospace" font-size="14">
+<rect width="120.4" height="44.8" fill="#000000"/>
+<g>
+<rect x="47.6" y="14" width="8.4" height="16.8" fill="#0000ee"/>
+</g>
+<g xml:space="preserve">
+<text y="26.6" fill="#e5e5e5"><tspan x="14">a</tspan><tspan x="22.4">` + "\ufffd" +
+		`</tspan><tspan x="30.8">b</tspan></text>
+<text y="26.6" fill="#cd0000" font-weight="bold"><tspan x="47.6">X</tspan></text>
+<text y="26.6" fill="#e5e5e5"><tspan x="81.2">日</tspan></text>
+<text y="26.6" fill="#e5e5e5"><tspan x="98">u</tspan></text>
+<rect x="98" y="27.72" width="8.4" height="0.98" fill="#e5e5e5"/>
+<rect x="98" y="22.4" width="8.4" height="0.98" fill="#e5e5e5"/>
+</g>
+</svg>
+`
+	got := ToSVG(in)
+	if got != want {
+		t.Errorf("ToSVG(%q) =\n%s\nwant\n%s", in, got, want)
+	}
+	if again := ToSVG(in); again != got {
+		t.Errorf("ToSVG(%q) is not deterministic", in)
+	}
+}
+
+func TestToSVGWellFormed(t *testing.T) {
+	for _, in := range []string{
+		"a\xffb",
+		"\xc3\x28\xe2\x82",
+		"bell\a back\b\bspace \x00nul \x7fdel \u0085nel \ufffe\uffff",
+		"<tag> & \"quotes\" 'apostrophes' ]]>",
+		"\033]8;;https://example.com/?a=1&b=2\033\\link\033]8;;\033\\",
+	} {
+		svgElements(t, ToSVG(in))
+		svgElements(t, SVGRenderer{Window: true, Title: in}.Render(in))
+	}
+}
+
+func TestToSVGGrid(t *testing.T) {
+	// Each grapheme cluster starts at its cell: 8.4 pixels per cell and a padding of 14 pixels.
+	in := "a日b\té👩‍💻x"
+	var got []string
+	for _, e := range svgElements(t, ToSVG(in)) {
+		if e.Name.Local == "tspan" {
+			got = append(got, svgAttr(e, "x"))
+		}
+	}
+	want := []string{"14", "22.4", "39.2", "81.2", "89.6", "106.4"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("ToSVG(%q) places the clusters at %v, want %v", in, got, want)
+	}
+	if strings.Contains(ToSVG(in), "textLength") {
+		t.Errorf("ToSVG(%q) stretches text instead of positioning it", in)
+	}
+}
+
+func TestSVGRendererWindow(t *testing.T) {
+	r := SVGRenderer{Window: true, Title: "go <test>", Columns: 40, FontSize: 10}
+	elements := svgElements(t, r.Render("ok\n"))
+	root := elements[0]
+	if w, h := svgAttr(root, "width"), svgAttr(root, "height"); w != "260" || h != "46" {
+		t.Errorf("window size = %s x %s, want 260 x 46", w, h)
+	}
+	circles := 0
+	for _, e := range elements {
+		if e.Name.Local == "circle" {
+			circles++
+		}
+	}
+	if circles != 3 {
+		t.Errorf("window frame has %d buttons, want 3", circles)
+	}
+	if doc := r.Render("ok\n"); !strings.Contains(doc, ">go &lt;test&gt;</text>") {
+		t.Errorf("window title missing or not escaped:\n%s", doc)
+	}
+}
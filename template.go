@@ -0,0 +1,107 @@
+package ansicolor
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// TemplateFuncs returns functions for styling text in text/template templates, rendered for the provided
+// Profile. Use WriterProfile to pick the profile matching the template's output, so that a template executed
+// into a file or a pipe produces plain text:
+//
+//	t := template.New("report").Funcs(ansicolor.TemplateFuncs(ansicolor.WriterProfile(os.Stdout)))
+//
+// The functions take the text as their last argument, so they can be used in pipelines:
+//
+//	{{ .Status | fg "bright green" }}   foreground color, as in ParseStyle
+//	{{ .Status | bg "red" }}            background color, as in ParseStyle after "on"
+//	{{ .Name | style "bold red" }}      any style accepted by ParseStyle
+//	{{ markup "[bold]done[/]" }}        markup text, see ParseMarkup
+//	{{ .Name | pad 20 }}                PadRight, measured by visible width
+//	{{ .Name | padleft 20 }}            PadLeft
+//	{{ .Name | center 20 }}             Center
+//	{{ .Path | truncate 30 }}           Truncate with an ellipsis
+//	{{ width .Name }}                   Width
+//
+// fg and bg accept a single color and fail on anything else, such as an option name. Text arguments may be of any
+// type and are formatted with fmt.Sprint. The functions can be re-bound with Template.Funcs before each execution
+// to render for another profile.
+func TemplateFuncs(p Profile) template.FuncMap {
+	styled := func(spec string, text any) (string, error) {
+		f, err := ParseStyle(spec)
+		if err != nil {
+			return "", err
+		}
+		return renderStyled(f, fmt.Sprint(text), p), nil
+	}
+	return template.FuncMap{
+		"fg": func(color string, text any) (string, error) {
+			f, err := parseColorStyle(color, false)
+			if err != nil {
+				return "", err
+			}
+			return renderStyled(f, fmt.Sprint(text), p), nil
+		},
+		"bg": func(color string, text any) (string, error) {
+			f, err := parseColorStyle(color, true)
+			if err != nil {
+				return "", err
+			}
+			return renderStyled(f, fmt.Sprint(text), p), nil
+		},
+		"style": styled,
+		"markup": func(s string) (string, error) {
+			t, err := ParseMarkup(s)
+			if err != nil {
+				return "", err
+			}
+			return t.Render(p), nil
+		},
+		"pad": func(width int, text any) string {
+			return PadRight(fmt.Sprint(text), width, nil)
+		},
+		"padleft": func(width int, text any) string {
+			return PadLeft(fmt.Sprint(text), width, nil)
+		},
+		"center": func(width int, text any) string {
+			return Center(fmt.Sprint(text), width, nil)
+		},
+		"truncate": func(width int, text any) string {
+			return Truncate(fmt.Sprint(text), width, Ellipsis)
+		},
+		"width": func(text any) int {
+			return Width(fmt.Sprint(text))
+		},
+	}
+}
+
+// parseColorStyle parses a color name as accepted by ParseStyle into a Format with that foreground or background
+// color. Anything other than a single color, such as an option name, is reported as an error wrapping
+// ErrInvalidStyle.
+func parseColorStyle(color string, background bool) (*Format, error) {
+	spec, kind := color, "foreground"
+	if background {
+		spec, kind = "on "+color, "background"
+	}
+	f, err := ParseStyle(spec)
+	if err != nil {
+		return nil, err
+	}
+	fg, bg := f.fg != nil || f.fgx != nil, f.bg != nil || f.bgx != nil
+	if fg == background || bg != background || f.ulx != nil || f.opts != 0 {
+		return nil, fmt.Errorf("%w: %q is not a %s color", ErrInvalidStyle, color, kind)
+	}
+	return f, nil
+}
+
+// renderStyled returns s displayed with the Format on a terminal with the provided Profile, followed by a reset.
+func renderStyled(f *Format, s string, p Profile) string {
+	if f.IsZero() {
+		return s
+	}
+	params := sgrTransition(NewFormat(), f, p)
+	if params == "" {
+		return s
+	}
+	return StartFormat + params + EndFormat + s + ClearString
+}
@@ -0,0 +1,74 @@
+package ansicolor
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want string
+		err  error
+	}{
+		{tmpl: `{{ "ok" | fg "green" }}`, want: "\033[32mok\033[0m"},
+		{tmpl: `{{ "ok" | fg "bright green" }}`, want: "\033[92mok\033[0m"},
+		{tmpl: `{{ "ok" | fg "#ff8800" }}`, want: "\033[38;2;255;136;0mok\033[0m"},
+		{tmpl: `{{ "ok" | bg "red" }}`, want: "\033[41mok\033[0m"},
+		{tmpl: `{{ "ok" | style "bold red" }}`, want: "\033[1;31mok\033[0m"},
+		{tmpl: `{{ "ok" | fg "bold" }}`, err: ErrInvalidStyle},
+		{tmpl: `{{ "ok" | fg "red on blue" }}`, err: ErrInvalidStyle},
+		{tmpl: `{{ "ok" | fg "" }}`, err: ErrInvalidStyle},
+		{tmpl: `{{ "ok" | bg "underline" }}`, err: ErrInvalidStyle},
+		{tmpl: `{{ "ok" | bg "red bold" }}`, err: ErrInvalidStyle},
+		{tmpl: `{{ "ok" | fg "nocolor" }}`, err: ErrInvalidStyle},
+		{tmpl: `[{{ "ab" | pad 4 }}][{{ "ab" | padleft 4 }}][{{ "ab" | center 5 }}]`, want: "[ab  ][  ab][ ab  ]"},
+		{tmpl: `{{ "abcdef" | truncate 4 }} {{ width "日本" }}`, want: "abc… 4"},
+	}
+	for _, tt := range tests {
+		tmpl := template.Must(template.New("t").Funcs(TemplateFuncs(ProfileTrueColor)).Parse(tt.tmpl))
+		var b strings.Builder
+		err := tmpl.Execute(&b, nil)
+		switch {
+		case tt.err != nil:
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: error = %v, want %v", tt.tmpl, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("%s: error = %v", tt.tmpl, err)
+		case b.String() != tt.want:
+			t.Errorf("%s = %q, want %q", tt.tmpl, b.String(), tt.want)
+		}
+	}
+}
+
+func TestWriterProfile(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	file, err := os.Create(t.TempDir() + "/out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for name, w := range map[string]*os.File{"null device": devNull, "file": file} {
+		if p := WriterProfile(w); !p.IsPlain() {
+			t.Errorf("WriterProfile(%s) = %+v, want ProfilePlain", name, p)
+		}
+	}
+	if p := WriterProfile(&strings.Builder{}); !p.IsPlain() {
+		t.Errorf("WriterProfile(strings.Builder) = %+v, want ProfilePlain", p)
+	}
+	// The master side of a pseudo-terminal, where available, is a terminal.
+	if ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0); err == nil {
+		defer ptmx.Close()
+		if p := WriterProfile(ptmx); p != GetProfile() {
+			t.Errorf("WriterProfile(/dev/ptmx) = %+v, want %+v", p, GetProfile())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ansicolor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeTerminal stands in for a terminal in raw mode: it answers the OSC color queries, XTGETTCAP and DECRQSS
+// requests and the device attributes requests written to it, like a real terminal would.
+type fakeTerminal struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	colors  map[string]RGB    // answered OSC color slots, such as "11" or "4;1"
+	caps    map[string]string // capabilities known to XTGETTCAP, which is not answered if nil
+	decrqss bool              // answer DECRQSS requests for the SGR state
+	approx  bool              // report 24-bit colors as palette entries, like terminals without 24-bit colors
+	sgr     string            // SGR state, updated by the SGR sequences written
+	noise   string            // written before every device attributes reply
+	silent  bool              // ignore device attributes requests
+	maxRead int               // maximum number of bytes returned by a Read, unlimited if 0
+	waiting int               // number of Reads blocked waiting for a reply
+	replies bytes.Buffer
+	written bytes.Buffer
+	lex     *Lexer
+}
+
+func newFakeTerminal(colors map[string]RGB) *fakeTerminal {
+	t := &fakeTerminal{colors: colors, sgr: "0"}
+	t.cond = sync.NewCond(&t.mu)
+	t.lex = NewLexer(t.answer)
+	return t
+}
+
+// answer queues the reply to a sequence written to the terminal.
+func (t *fakeTerminal) answer(tok Token) {
+	switch {
+	case tok.Kind == TokenOSC && strings.HasSuffix(tok.Data, ";?"):
+		slot := strings.TrimSuffix(tok.Data, ";?")
+		if c, ok := t.colors[slot]; ok {
+			fmt.Fprintf(&t.replies, "%s%s;rgb:%02x%02x/%02x%02x/%02x%02x%s", StartOSC, slot, c.R, c.R, c.G, c.G, c.B,
+				c.B, EndOSC)
+		}
+	case tok.Kind == TokenDCS && tok.Intermediates == "+" && tok.Final == 'q' && t.caps != nil:
+		name, _ := hex.DecodeString(tok.Data)
+		if v, ok := t.caps[string(name)]; ok {
+			fmt.Fprintf(&t.replies, "%s1+r%s=%s%s", StartDCS, tok.Data, hex.EncodeToString([]byte(v)), EndDCS)
+		} else {
+			fmt.Fprintf(&t.replies, "%s0+r%s%s", StartDCS, tok.Data, EndDCS)
+		}
+	case tok.Kind == TokenDCS && tok.Intermediates == "$" && tok.Final == 'q' && tok.Data == "m" && t.decrqss:
+		state := t.sgr
+		if t.approx {
+			state = strings.ReplaceAll(state, "8;2;1;2;3", "8;5;16")
+		}
+		fmt.Fprintf(&t.replies, "%s1$r%sm%s", StartDCS, state, EndDCS)
+	case tok.IsSGR():
+		if tok.Params == "" || tok.Params == "0" || strings.HasPrefix(tok.Params, "0;") {
+			t.sgr = "0" + strings.TrimPrefix(tok.Params, "0")
+		} else {
+			t.sgr += ";" + tok.Params
+		}
+	case tok.Kind == TokenCSI && tok.Prefix == 0 && tok.Final == 'c' && !t.silent:
+		t.replies.WriteString(t.noise + "\033[?62;22c")
+	}
+}
+
+func (t *fakeTerminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.written.Write(p)
+	_, _ = t.lex.Write(p)
+	t.cond.Broadcast()
+	return len(p), nil
+}
+
+// Read blocks until a reply is available, like a terminal that has nothing to say.
+func (t *fakeTerminal) Read(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for t.replies.Len() == 0 {
+		t.waiting++
+		t.cond.Wait()
+		t.waiting--
+	}
+	if t.maxRead > 0 && len(p) > t.maxRead {
+		p = p[:t.maxRead]
+	}
+	return t.replies.Read(p)
+}
+
+// blockedReads returns the number of Reads waiting for a reply, after giving pending reads time to start.
+func (t *fakeTerminal) blockedReads() int {
+	time.Sleep(20 * time.Millisecond)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.waiting
+}
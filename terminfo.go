@@ -0,0 +1,324 @@
+package ansicolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrTerminfoNotFound indicates that no compiled terminfo entry exists for a terminal name.
+// ErrTerminfoInvalid indicates that a compiled terminfo entry is malformed.
+var (
+	ErrTerminfoNotFound = errors.New("terminfo entry not found")
+	ErrTerminfoInvalid  = errors.New("invalid terminfo entry")
+)
+
+// terminfoMagicLegacy identifies compiled entries storing numbers as 16-bit values.
+// terminfoMagic32 identifies the extended number format storing numbers as 32-bit values.
+const (
+	terminfoMagicLegacy = 0o432
+	terminfoMagic32     = 0o1036
+)
+
+// terminfoDefaultDirs lists the system directories searched for compiled terminfo entries.
+var terminfoDefaultDirs = []string{
+	"/etc/terminfo",
+	"/lib/terminfo",
+	"/usr/share/terminfo",
+	"/usr/lib/terminfo",
+	"/usr/share/lib/terminfo",
+}
+
+// Terminfo holds the capabilities of a compiled terminfo entry. Standard and extended (user-defined)
+// capabilities are stored together, keyed by their short capability name.
+type Terminfo struct {
+	Names   []string
+	Bools   map[string]bool
+	Numbers map[string]int
+	Strings map[string]string
+}
+
+// LoadTerminfo finds and parses the compiled terminfo entry for the terminal name, searching $TERMINFO,
+// ~/.terminfo, $TERMINFO_DIRS and the system terminfo directories in that order.
+// Returns ErrTerminfoNotFound if no entry exists.
+func LoadTerminfo(term string) (*Terminfo, error) {
+	if term == "" || strings.ContainsAny(term, "/\\") || term == "." || term == ".." {
+		return nil, ErrTerminfoNotFound
+	}
+	for _, dir := range terminfoDirs() {
+		for _, sub := range []string{term[:1], fmt.Sprintf("%02x", term[0])} {
+			data, err := os.ReadFile(filepath.Join(dir, sub, term))
+			if err != nil {
+				continue
+			}
+			return ParseTerminfo(data)
+		}
+	}
+	return nil, ErrTerminfoNotFound
+}
+
+// terminfoDirs returns the directories to search for compiled terminfo entries, in order of precedence.
+func terminfoDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	if list, ok := os.LookupEnv("TERMINFO_DIRS"); ok {
+		for _, dir := range strings.Split(list, ":") {
+			if dir == "" {
+				// An empty element stands for the system directories.
+				dirs = append(dirs, terminfoDefaultDirs...)
+				continue
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, terminfoDefaultDirs...)
+}
+
+// ParseTerminfo parses a compiled terminfo entry in either the legacy or the extended 32-bit number format,
+// including any extended capabilities such as Tc or RGB.
+func ParseTerminfo(data []byte) (*Terminfo, error) {
+	r := &terminfoReader{data: data}
+	magic := r.short()
+	var numSize int
+	switch magic {
+	case terminfoMagicLegacy:
+		numSize = 2
+	case terminfoMagic32:
+		numSize = 4
+	default:
+		return nil, ErrTerminfoInvalid
+	}
+	nameSize, boolCount, numCount, strCount, tableSize := r.short(), r.short(), r.short(), r.short(), r.short()
+	if r.err != nil || nameSize < 0 || boolCount < 0 || numCount < 0 || strCount < 0 || tableSize < 0 {
+		return nil, ErrTerminfoInvalid
+	}
+
+	ti := &Terminfo{
+		Bools:   make(map[string]bool),
+		Numbers: make(map[string]int),
+		Strings: make(map[string]string),
+	}
+	names := strings.TrimRight(string(r.bytes(nameSize)), "\x00")
+	ti.Names = strings.Split(names, "|")
+
+	bools := r.bytes(boolCount)
+	for i, v := range bools {
+		if i < len(terminfoBoolNames) && v == 1 {
+			ti.Bools[terminfoBoolNames[i]] = true
+		}
+	}
+	r.align()
+	for i := 0; i < numCount; i++ {
+		v := r.number(numSize)
+		if i < len(terminfoNumNames) && v >= 0 {
+			ti.Numbers[terminfoNumNames[i]] = v
+		}
+	}
+	offsets := make([]int, strCount)
+	for i := range offsets {
+		offsets[i] = r.short()
+	}
+	table := r.bytes(tableSize)
+	if r.err != nil {
+		return nil, ErrTerminfoInvalid
+	}
+	for i, off := range offsets {
+		if i >= len(terminfoStringNames) || off < 0 {
+			continue
+		}
+		s, ok := terminfoString(table, off)
+		if !ok {
+			return nil, ErrTerminfoInvalid
+		}
+		ti.Strings[terminfoStringNames[i]] = s
+	}
+
+	r.align()
+	if r.pos < len(r.data) {
+		if err := ti.parseExtended(r, numSize); err != nil {
+			return nil, err
+		}
+	}
+	return ti, nil
+}
+
+// parseExtended parses the extended capability section that follows the standard capabilities.
+func (ti *Terminfo) parseExtended(r *terminfoReader, numSize int) error {
+	boolCount, numCount, strCount, itemCount, tableSize := r.short(), r.short(), r.short(), r.short(), r.short()
+	if r.err != nil || boolCount < 0 || numCount < 0 || strCount < 0 || itemCount < 0 || tableSize < 0 {
+		return ErrTerminfoInvalid
+	}
+	bools := r.bytes(boolCount)
+	r.align()
+	nums := make([]int, numCount)
+	for i := range nums {
+		nums[i] = r.number(numSize)
+	}
+	offsets := make([]int, strCount)
+	for i := range offsets {
+		offsets[i] = r.short()
+	}
+	nameOffsets := make([]int, boolCount+numCount+strCount)
+	for i := range nameOffsets {
+		nameOffsets[i] = r.short()
+	}
+	table := r.bytes(tableSize)
+	if r.err != nil {
+		return ErrTerminfoInvalid
+	}
+
+	// The string table holds the capability values first and the capability names after them.
+	values := make([]string, strCount)
+	namesStart := 0
+	for i, off := range offsets {
+		if off < 0 {
+			continue
+		}
+		s, ok := terminfoString(table, off)
+		if !ok {
+			return ErrTerminfoInvalid
+		}
+		values[i] = s
+		if end := off + len(s) + 1; end > namesStart {
+			namesStart = end
+		}
+	}
+	if namesStart > len(table) {
+		return ErrTerminfoInvalid
+	}
+	names := make([]string, len(nameOffsets))
+	for i, off := range nameOffsets {
+		s, ok := terminfoString(table[namesStart:], off)
+		if !ok {
+			return ErrTerminfoInvalid
+		}
+		names[i] = s
+	}
+
+	for i, v := range bools {
+		if v == 1 {
+			ti.Bools[names[i]] = true
+		}
+	}
+	for i, v := range nums {
+		if v >= 0 {
+			ti.Numbers[names[boolCount+i]] = v
+		}
+	}
+	for i, off := range offsets {
+		if off >= 0 {
+			ti.Strings[names[boolCount+numCount+i]] = values[i]
+		}
+	}
+	return nil
+}
+
+// terminfoString returns the NUL-terminated string starting at offset off in table.
+func terminfoString(table []byte, off int) (string, bool) {
+	if off < 0 || off >= len(table) {
+		return "", false
+	}
+	end := bytes.IndexByte(table[off:], 0)
+	if end < 0 {
+		return "", false
+	}
+	return string(table[off : off+end]), true
+}
+
+// Name returns the primary name of the terminal described by the entry.
+func (ti *Terminfo) Name() string {
+	if len(ti.Names) == 0 {
+		return ""
+	}
+	return ti.Names[0]
+}
+
+// Flag reports whether the boolean capability is present.
+func (ti *Terminfo) Flag(name string) bool {
+	return ti.Bools[name]
+}
+
+// Num returns the value of the numeric capability and whether it is present.
+func (ti *Terminfo) Num(name string) (int, bool) {
+	v, ok := ti.Numbers[name]
+	return v, ok
+}
+
+// Str returns the value of the string capability and whether it is present.
+func (ti *Terminfo) Str(name string) (string, bool) {
+	v, ok := ti.Strings[name]
+	return v, ok
+}
+
+// Has reports whether the entry defines the capability with any type.
+func (ti *Terminfo) Has(name string) bool {
+	_, num := ti.Numbers[name]
+	_, str := ti.Strings[name]
+	return ti.Bools[name] || num || str
+}
+
+// Colors returns the number of colors the terminal supports, or 0 if the entry has no colors capability.
+func (ti *Terminfo) Colors() int {
+	return ti.Numbers["colors"]
+}
+
+// TrueColor reports whether the entry advertises 24-bit color through the Tc or RGB extended capabilities, or
+// through a colors capability of 2^24.
+func (ti *Terminfo) TrueColor() bool {
+	return ti.Has("Tc") || ti.Has("RGB") || ti.Colors() >= 1<<24
+}
+
+// terminfoReader reads little-endian values from a compiled terminfo entry, remembering the first error.
+type terminfoReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// bytes returns the next n bytes of the entry.
+func (r *terminfoReader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.data) {
+		r.err = ErrTerminfoInvalid
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// short returns the next signed 16-bit value of the entry.
+func (r *terminfoReader) short() int {
+	b := r.bytes(2)
+	if b == nil {
+		return -1
+	}
+	return int(int16(binary.LittleEndian.Uint16(b)))
+}
+
+// number returns the next signed numeric capability value, which is 2 or 4 bytes wide.
+func (r *terminfoReader) number(size int) int {
+	if size == 2 {
+		return r.short()
+	}
+	b := r.bytes(4)
+	if b == nil {
+		return -1
+	}
+	return int(int32(binary.LittleEndian.Uint32(b)))
+}
+
+// align skips a padding byte so the next value starts on an even offset.
+func (r *terminfoReader) align() {
+	if r.pos%2 == 1 && r.pos < len(r.data) {
+		r.pos++
+	}
+}
@@ -0,0 +1,287 @@
+package ansicolor
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// terminfoEntry describes a terminfo entry to compile with compileTerminfo.
+type terminfoEntry struct {
+	names   string
+	numSize int // 2 for the legacy format, 4 for the 32-bit format
+	bools   []string
+	nums    map[string]int
+	strs    map[string]string
+	extBool []string // extended capabilities
+	extNum  map[string]int
+	extStr  map[string]string
+}
+
+// terminfoWriter writes little-endian values, like the terminfo compiler.
+type terminfoWriter []byte
+
+func (w *terminfoWriter) short(v int) {
+	*w = binary.LittleEndian.AppendUint16(*w, uint16(int16(v)))
+}
+
+func (w *terminfoWriter) number(size, v int) {
+	if size == 2 {
+		w.short(v)
+		return
+	}
+	*w = binary.LittleEndian.AppendUint32(*w, uint32(int32(v)))
+}
+
+func (w *terminfoWriter) align() {
+	if len(*w)%2 == 1 {
+		*w = append(*w, 0)
+	}
+}
+
+func terminfoIndex(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	panic("unknown capability " + name)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compileTerminfo compiles an entry in the format read by ParseTerminfo.
+func compileTerminfo(e terminfoEntry) []byte {
+	bools := []byte{}
+	for _, name := range e.bools {
+		i := terminfoIndex(terminfoBoolNames[:], name)
+		for len(bools) <= i {
+			bools = append(bools, 0)
+		}
+		bools[i] = 1
+	}
+	nums := []int{}
+	for name, v := range e.nums {
+		i := terminfoIndex(terminfoNumNames[:], name)
+		for len(nums) <= i {
+			nums = append(nums, -1)
+		}
+		nums[i] = v
+	}
+	offsets, table := []int{}, []byte{}
+	for _, name := range sortedKeys(e.strs) {
+		i := terminfoIndex(terminfoStringNames[:], name)
+		for len(offsets) <= i {
+			offsets = append(offsets, -1)
+		}
+		offsets[i] = len(table)
+		table = append(append(table, e.strs[name]...), 0)
+	}
+
+	w := terminfoWriter{}
+	magic := terminfoMagicLegacy
+	if e.numSize == 4 {
+		magic = terminfoMagic32
+	}
+	for _, v := range []int{magic, len(e.names) + 1, len(bools), len(nums), len(offsets), len(table)} {
+		w.short(v)
+	}
+	w = append(append(w, e.names...), 0)
+	w = append(w, bools...)
+	w.align()
+	for _, v := range nums {
+		w.number(e.numSize, v)
+	}
+	for _, off := range offsets {
+		w.short(off)
+	}
+	w = append(w, table...)
+	if e.extBool == nil && e.extNum == nil && e.extStr == nil {
+		return w
+	}
+
+	w.align()
+	extNums, extStrs := sortedKeys(e.extNum), sortedKeys(e.extStr)
+	names := append(append(append([]string{}, e.extBool...), extNums...), extStrs...)
+	values, nameTable := []byte{}, []byte{}
+	valueOffsets, nameOffsets := []int{}, []int{}
+	for _, name := range extStrs {
+		valueOffsets = append(valueOffsets, len(values))
+		values = append(append(values, e.extStr[name]...), 0)
+	}
+	for _, name := range names {
+		nameOffsets = append(nameOffsets, len(nameTable))
+		nameTable = append(append(nameTable, name...), 0)
+	}
+	for _, v := range []int{len(e.extBool), len(extNums), len(extStrs), len(extStrs) + len(names),
+		len(values) + len(nameTable)} {
+		w.short(v)
+	}
+	for range e.extBool {
+		w = append(w, 1)
+	}
+	w.align()
+	for _, name := range extNums {
+		w.number(e.numSize, e.extNum[name])
+	}
+	for _, off := range append(valueOffsets, nameOffsets...) {
+		w.short(off)
+	}
+	return append(append(w, values...), nameTable...)
+}
+
+func TestParseTerminfo(t *testing.T) {
+	ti, err := ParseTerminfo(compileTerminfo(terminfoEntry{
+		names:   "xterm-test|test terminal",
+		numSize: 2,
+		bools:   []string{"am", "xenl"},
+		nums:    map[string]int{"cols": 80, "colors": 256},
+		strs:    map[string]string{"bold": "\033[1m", "setaf": "\033[38;5;%p1%dm"},
+		extBool: []string{"XT"},
+		extNum:  map[string]int{"U8": 1},
+		extStr:  map[string]string{"Smulx": "\033[4:%p1%dm"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ti.Name() != "xterm-test" || len(ti.Names) != 2 {
+		t.Errorf("Names = %q", ti.Names)
+	}
+	if !ti.Flag("am") || !ti.Flag("xenl") || ti.Flag("bw") || !ti.Flag("XT") {
+		t.Errorf("Bools = %v", ti.Bools)
+	}
+	if n, ok := ti.Num("cols"); !ok || n != 80 || ti.Colors() != 256 {
+		t.Errorf("Numbers = %v", ti.Numbers)
+	}
+	if _, ok := ti.Num("lines"); ok {
+		t.Error("Num(lines) reported as present")
+	}
+	if n, ok := ti.Num("U8"); !ok || n != 1 {
+		t.Errorf("Num(U8) = %d, %v", n, ok)
+	}
+	if s, ok := ti.Str("setaf"); !ok || s != "\033[38;5;%p1%dm" {
+		t.Errorf("Str(setaf) = %q, %v", s, ok)
+	}
+	if s, ok := ti.Str("Smulx"); !ok || s != "\033[4:%p1%dm" {
+		t.Errorf("Str(Smulx) = %q, %v", s, ok)
+	}
+	if !ti.Has("bold") || !ti.Has("XT") || ti.Has("sitm") || ti.TrueColor() {
+		t.Errorf("unexpected capabilities: %+v", ti)
+	}
+}
+
+func TestParseTerminfoInvalid(t *testing.T) {
+	valid := compileTerminfo(terminfoEntry{names: "t", numSize: 2, strs: map[string]string{"bold": "\033[1m"}})
+	badOffset := append([]byte{}, valid...)
+	// The only string offset follows the 12-byte header and the 2-byte name "t\x00".
+	binary.LittleEndian.PutUint16(badOffset[14:], 100)
+	tests := map[string][]byte{
+		"empty":            nil,
+		"bad magic":        {0x1a, 0x02, 0, 0},
+		"truncated":        valid[:len(valid)-3],
+		"offset too large": badOffset,
+	}
+	for name, data := range tests {
+		if _, err := ParseTerminfo(data); !errors.Is(err, ErrTerminfoInvalid) {
+			t.Errorf("%s: ParseTerminfo() error = %v, want ErrTerminfoInvalid", name, err)
+		}
+	}
+}
+
+func TestProfileFromTerminfo(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry terminfoEntry
+		want  Profile
+	}{
+		{
+			name:  "no colors",
+			entry: terminfoEntry{numSize: 2, strs: map[string]string{"bold": "\033[1m", "smul": "\033[4m"}},
+			want:  Profile{Options: SGROptBold | SGROptUnderline},
+		},
+		{
+			name:  "colors without setaf",
+			entry: terminfoEntry{numSize: 2, nums: map[string]int{"colors": 8}},
+			want:  Profile{},
+		},
+		{
+			name: "8 colors",
+			entry: terminfoEntry{numSize: 2, nums: map[string]int{"colors": 8},
+				strs: map[string]string{"setaf": "x", "rev": "\033[7m"}},
+			want: Profile{Colors: LevelBasic, Options: SGROptReverse},
+		},
+		{
+			name: "256 colors",
+			entry: terminfoEntry{numSize: 2, nums: map[string]int{"colors": 256},
+				strs: map[string]string{"setaf": "x", "sitm": "\033[3m", "blink": "\033[5m"}},
+			want: Profile{Colors: LevelANSI256, Options: SGROptItalic | SGROptBlink | SGROptFastBlink},
+		},
+		{
+			name:  "direct colors",
+			entry: terminfoEntry{numSize: 4, nums: map[string]int{"colors": 1 << 24}, strs: map[string]string{"setaf": "x"}},
+			want:  Profile{Colors: LevelTrueColor},
+		},
+		{
+			name: "Tc",
+			entry: terminfoEntry{numSize: 2, nums: map[string]int{"colors": 256}, strs: map[string]string{"setaf": "x"},
+				extBool: []string{"Tc"}},
+			want: Profile{Colors: LevelTrueColor},
+		},
+		{
+			name: "RGB and Smulx",
+			entry: terminfoEntry{numSize: 4, nums: map[string]int{"colors": 256}, extBool: []string{"RGB"},
+				extStr: map[string]string{"Smulx": "\033[4:%p1%dm"}},
+			want: Profile{Colors: LevelTrueColor, Options: SGROptDoubleUnderline},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.entry.names = "test"
+			ti, err := ParseTerminfo(compileTerminfo(tt.entry))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ProfileFromTerminfo(ti); got != tt.want {
+				t.Errorf("ProfileFromTerminfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTerminfo(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TERMINFO", dir)
+	t.Setenv("TERMINFO_DIRS", "")
+	entry := compileTerminfo(terminfoEntry{names: "fancy", numSize: 2, nums: map[string]int{"colors": 16},
+		strs: map[string]string{"setaf": "x"}})
+	// Entries are stored under their first letter, or its hexadecimal code on case-insensitive file systems.
+	for _, path := range []string{filepath.Join("f", "fancy"), filepath.Join("62", "basic")} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), entry, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, term := range []string{"fancy", "basic"} {
+		p, err := ProfileForTerm(term)
+		if err != nil || p.Colors != LevelANSI {
+			t.Errorf("ProfileForTerm(%q) = %+v, %v", term, p, err)
+		}
+	}
+	for _, term := range []string{"", "..", "f/fancy", "missing-terminal-type"} {
+		if _, err := LoadTerminfo(term); !errors.Is(err, ErrTerminfoNotFound) {
+			t.Errorf("LoadTerminfo(%q) error = %v, want ErrTerminfoNotFound", term, err)
+		}
+	}
+}
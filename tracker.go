@@ -0,0 +1,177 @@
+package ansicolor
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrTrackerStackEmpty indicates that Pop was called on a Tracker without a matching Push.
+var ErrTrackerStackEmpty = errors.New("tracker format stack is empty")
+
+// Tracker wraps an io.Writer and keeps track of the SGR state of the terminal behind it. Every SGR sequence
+// passing through Write, including the ones embedded in text, updates the tracked state, and Set only emits
+// the parameters needed to move from the current state to the requested Format.
+//
+// Sequences emitted by Set are rendered for the global Profile (see SetProfile). A Tracker is not safe for
+// concurrent use.
+type Tracker struct {
+	w     io.Writer
+	state *Format
+	stack []*Format
+	lex   *Lexer
+}
+
+// NewTracker creates a new Tracker writing to w. The terminal is assumed to start without any attributes set.
+func NewTracker(w io.Writer) *Tracker {
+	t := &Tracker{
+		w:     w,
+		state: NewFormat(),
+	}
+	t.lex = NewLexer(t.token)
+	return t
+}
+
+// Write writes p to the underlying writer, updating the tracked state with any SGR sequences it contains.
+// Sequences split across several writes are tracked once they are complete.
+func (t *Tracker) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	_, _ = t.lex.Write(p[:n])
+	return n, err
+}
+
+// WriteString writes s to the underlying writer, updating the tracked state with any SGR sequences it contains.
+func (t *Tracker) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// token updates the tracked state with the SGR sequences found in the written output.
+func (t *Tracker) token(tok Token) {
+	if tok.IsSGR() {
+		t.state = t.state.ApplySGR(tok.Params)
+	}
+}
+
+// Current returns the Format currently in effect on the terminal.
+func (t *Tracker) Current() *Format {
+	return t.state
+}
+
+// Set changes the terminal attributes to exactly the provided Format, emitting only the parameters that differ
+// from the current state. Nothing is written if the Format is already in effect.
+func (t *Tracker) Set(f *Format) error {
+	if f == nil {
+		f = NewFormat()
+	}
+	if t.state.Equal(f) {
+		return nil
+	}
+	if params := sgrTransition(t.state, f, GetProfile()); params != "" {
+		if _, err := io.WriteString(t.w, StartFormat+params+EndFormat); err != nil {
+			return err
+		}
+	}
+	t.state = f
+	return nil
+}
+
+// Reset clears every attribute, writing a reset sequence unless the terminal is already in its default state.
+func (t *Tracker) Reset() error {
+	return t.Set(NewFormat())
+}
+
+// Push saves the current Format on a stack so that it can be restored with Pop.
+func (t *Tracker) Push() {
+	t.stack = append(t.stack, t.state)
+}
+
+// Pop restores the Format saved by the most recent Push.
+// Returns ErrTrackerStackEmpty if there is no saved Format.
+func (t *Tracker) Pop() error {
+	if len(t.stack) == 0 {
+		return ErrTrackerStackEmpty
+	}
+	f := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+	return t.Set(f)
+}
+
+// Print sets the provided Format and writes s.
+func (t *Tracker) Print(f *Format, s string) error {
+	if err := t.Set(f); err != nil {
+		return err
+	}
+	_, err := t.WriteString(s)
+	return err
+}
+
+// sgrTransition returns the SGR parameters that move a terminal from the cur Format to the target Format,
+// rendered for the Profile. Whichever is shorter of an incremental change and a reset followed by the target's
+// attributes is returned.
+func sgrTransition(cur, target *Format, p Profile) string {
+	if p.IsPlain() {
+		return ""
+	}
+	if target.IsZero() {
+		return "0"
+	}
+	full := []string{"0"}
+	if fg := target.fgShort(p.Colors); fg != "" && (target.fgx != nil || !equalFg(target.fg, nil)) {
+		full = append(full, fg)
+	}
+	if bg := target.bgShort(p.Colors); bg != "" && (target.bgx != nil || !equalBg(target.bg, nil)) {
+		full = append(full, bg)
+	}
+	if ul := target.ulShort(p.Colors); ul != "" {
+		full = append(full, ul)
+	}
+	if opts := target.opts & p.Options; opts != 0 {
+		full = append(full, opts.String())
+	}
+
+	var codes []string
+	// Clear removed options; a clearer may remove more than one option, so re-enable the ones still wanted.
+	removed := cur.opts &^ target.opts
+	var cleared, reenable SGROption
+	for _, opt := range sgrOptOrder {
+		if !removed.Has(opt) || cleared.Has(opt) {
+			continue
+		}
+		clearer := SGROptClearerLookup[opt]
+		codes = append(codes, clearer.Short())
+		group := sgrClearerOptions[int(clearer)]
+		cleared.Set(group)
+		reenable.Set(target.opts & group)
+	}
+	if added := (target.opts&^cur.opts | reenable) & p.Options; added != 0 {
+		codes = append(codes, added.String())
+	}
+	if !equalFg(cur.fg, target.fg) || !equalColor(cur.fgx, target.fgx) {
+		if fg := target.fgShort(p.Colors); fg != "" {
+			codes = append(codes, fg)
+		} else {
+			codes = append(codes, FgDefault.Short())
+		}
+	}
+	if !equalBg(cur.bg, target.bg) || !equalColor(cur.bgx, target.bgx) {
+		if bg := target.bgShort(p.Colors); bg != "" {
+			codes = append(codes, bg)
+		} else {
+			codes = append(codes, BgDefault.Short())
+		}
+	}
+	if !equalColor(cur.ulx, target.ulx) && p.Colors >= LevelANSI256 {
+		if ul := target.ulShort(p.Colors); ul != "" {
+			codes = append(codes, ul)
+		} else {
+			codes = append(codes, strconv.Itoa(sgrUlDefault))
+		}
+	}
+
+	incremental, reset := strings.Join(codes, ";"), strings.Join(full, ";")
+	if len(reset) < len(incremental) {
+		return reset
+	}
+	return incremental
+}
@@ -0,0 +1,160 @@
+package ansicolor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTrackerSet(t *testing.T) {
+	defer SetProfile(GetProfile())
+	SetProfile(ProfileTrueColor)
+	bold := NewFormat().WithOption(SGROptBold)
+	faint := NewFormat().WithOption(SGROptFaint)
+	tests := []struct {
+		name   string
+		state  string
+		target *Format
+		want   string
+	}{
+		{name: "add option", target: bold, want: "\033[1m"},
+		{name: "unchanged", state: "\033[1m", target: bold, want: ""},
+		{name: "nil", state: "\033[1m", target: nil, want: "\033[0m"},
+		{name: "remove option", state: "\033[1;3m", target: NewFormat().WithOption(SGROptItalic), want: "\033[22m"},
+		// 22 clears both bold and faint, so faint is enabled again.
+		{name: "shared clearer", state: "\033[1;2;31m", target: faint.WithForeground(FgRed), want: "\033[22;2m"},
+		{name: "shared clearer reset", state: "\033[1;2m", target: faint, want: "\033[0;2m"},
+		{
+			name:   "double underline",
+			state:  "\033[4;21;34m",
+			target: NewFormat().WithOption(SGROptUnderline).WithForeground(FgBlue),
+			want:   "\033[24;4m",
+		},
+		{name: "remove color", state: "\033[1;31m", target: bold, want: "\033[39m"},
+		{name: "change color", state: "\033[31m", target: NewFormat().WithForeground(FgBlue), want: "\033[34m"},
+		{
+			name:   "add background",
+			state:  "\033[38;2;1;2;3m",
+			target: NewFormat().WithForegroundColor(TrueColor(1, 2, 3)).WithBackground(BgRed),
+			want:   "\033[41m",
+		},
+		{
+			name:   "remove underline color",
+			state:  "\033[4;58;5;1m",
+			target: NewFormat().WithOption(SGROptUnderline),
+			want:   "\033[59m",
+		},
+		{
+			name:   "shorter reset",
+			state:  "\033[1;3;4;9;31;44m",
+			target: NewFormat().WithOption(SGROptStrike),
+			want:   "\033[0;9m",
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		tr := NewTracker(&buf)
+		_, _ = tr.WriteString(tt.state)
+		buf.Reset()
+		if err := tr.Set(tt.target); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: Set() wrote %q, want %q", tt.name, buf.String(), tt.want)
+		}
+		want := tt.target
+		if want == nil {
+			want = NewFormat()
+		}
+		if !tr.Current().Equal(want) {
+			t.Errorf("%s: Current() = %q, want %q", tt.name, tr.Current().String(), want.String())
+		}
+	}
+}
+
+func TestTrackerProfile(t *testing.T) {
+	defer SetProfile(GetProfile())
+	target := NewFormat().WithForegroundColor(TrueColor(0xff, 0x87, 0)).WithOption(SGROptItalic)
+	tests := []struct {
+		p    Profile
+		want string
+	}{
+		{p: ProfileTrueColor, want: "\033[3;38;2;255;135;0m"},
+		{p: Profile{Colors: LevelANSI256}, want: "\033[38;5;208m"},
+		{p: ProfilePlain, want: ""},
+	}
+	for _, tt := range tests {
+		SetProfile(tt.p)
+		var buf bytes.Buffer
+		tr := NewTracker(&buf)
+		if err := tr.Set(target); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("Set() with profile %+v wrote %q, want %q", tt.p, buf.String(), tt.want)
+		}
+		if !tr.Current().Equal(target) {
+			t.Errorf("Current() with profile %+v = %q, want the target", tt.p, tr.Current().String())
+		}
+	}
+}
+
+func TestTrackerPushPop(t *testing.T) {
+	defer SetProfile(GetProfile())
+	SetProfile(ProfileTrueColor)
+	var buf bytes.Buffer
+	tr := NewTracker(&buf)
+	if err := tr.Pop(); err != ErrTrackerStackEmpty {
+		t.Errorf("Pop() on an empty stack error = %v, want ErrTrackerStackEmpty", err)
+	}
+
+	red := NewFormat().WithForeground(FgRed)
+	_ = tr.Print(red, "a")
+	tr.Push()
+	_ = tr.Print(red.WithOption(SGROptBold), "b")
+	tr.Push()
+	_ = tr.Print(NewFormat().WithBackground(BgBlue), "c")
+	for i := 0; i < 2; i++ {
+		if err := tr.Pop(); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tr.WriteString("d")
+	}
+	if err := tr.Pop(); err != ErrTrackerStackEmpty {
+		t.Errorf("third Pop() error = %v, want ErrTrackerStackEmpty", err)
+	}
+	if err := tr.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	want := "\033[31ma\033[1mb\033[0;44mc\033[0;31;1md\033[22md\033[0m"
+	if buf.String() != want {
+		t.Errorf("wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTrackerWrite(t *testing.T) {
+	defer SetProfile(GetProfile())
+	SetProfile(ProfileTrueColor)
+	var buf bytes.Buffer
+	tr := NewTracker(&buf)
+	// A sequence split across writes is tracked once it is complete.
+	for _, s := range []string{"x\033[1", ";3", "1mred\033", "[3m"} {
+		if _, err := tr.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := NewFormat().WithForeground(FgRed).WithOption(SGROptBold).WithOption(SGROptItalic)
+	if !tr.Current().Equal(want) {
+		t.Errorf("Current() = %q, want %q", tr.Current().String(), want.String())
+	}
+	buf.Reset()
+	if err := tr.Set(want.WithForeground(FgGreen)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "\033[32m" {
+		t.Errorf("Set() after split writes wrote %q, want %q", buf.String(), "\033[32m")
+	}
+	_, _ = tr.WriteString("\033[0m")
+	if !tr.Current().IsZero() {
+		t.Errorf("Current() after a reset = %q, want no attributes", tr.Current().String())
+	}
+}
@@ -0,0 +1,120 @@
+package ansicolor
+
+import (
+	"strings"
+)
+
+// Truncate shortens s to at most width terminal cells, as measured by Width, and appends tail (e.g. "…") when
+// anything was cut. The tail counts towards the width. Escape sequences and grapheme clusters are never split:
+// a wide character that does not fit is dropped as a whole, escape sequences before the cut are kept, and any
+// style or hyperlink still open at the cut is closed before the tail is appended. s is returned unchanged if it
+// already fits.
+func Truncate(s string, width int, tail string) string {
+	if width < 0 {
+		width = 0
+	}
+	if Width(s) <= width {
+		return s
+	}
+	tailWidth := Width(tail)
+	if tailWidth > width {
+		tail = Truncate(tail, width, "")
+		tailWidth = Width(tail)
+	}
+	budget := width - tailWidth
+
+	var b strings.Builder
+	style := newTextStyle()
+	w := 0
+cut:
+	for _, tok := range Tokenize(s) {
+		if tok.Kind != TokenText {
+			style.update(tok)
+			b.WriteString(tok.Raw)
+			continue
+		}
+		for text := tok.Raw; text != ""; {
+			cluster, cw := nextGrapheme(text)
+			if w+cw > budget {
+				break cut
+			}
+			b.WriteString(cluster)
+			w += cw
+			text = text[len(cluster):]
+		}
+	}
+	b.WriteString(style.close())
+	b.WriteString(tail)
+	return b.String()
+}
+
+// oscHyperlink is the OSC code of hyperlinks: OSC 8 ; params ; URI ST opens a link and an empty URI closes it.
+const oscHyperlink = "8"
+
+// closeHyperlink is the OSC 8 sequence that ends the current hyperlink.
+const closeHyperlink = StartOSC + oscHyperlink + ";;" + EndOSC
+
+// textStyle follows the SGR attributes and the hyperlink in effect while a string is taken apart, so that the
+// pieces can be closed and re-opened to display like the original.
+type textStyle struct {
+	format *Format
+	link   string // the raw OSC 8 sequence of the open hyperlink, empty if none
+}
+
+// newTextStyle returns a textStyle for the start of a string: no attributes and no hyperlink.
+func newTextStyle() textStyle {
+	return textStyle{format: NewFormat()}
+}
+
+// update applies an escape sequence token to the style.
+func (s *textStyle) update(tok Token) {
+	switch {
+	case tok.IsSGR():
+		s.format = s.format.ApplySGR(tok.Params)
+	case tok.Kind == TokenOSC:
+		if uri, ok := hyperlinkURI(tok); ok {
+			s.link = ""
+			if uri != "" {
+				s.link = tok.Raw
+			}
+		}
+	}
+}
+
+// isOpen reports whether the style has attributes or a hyperlink that must be closed.
+func (s *textStyle) isOpen() bool {
+	return s.link != "" || !s.format.IsZero()
+}
+
+// open returns the escape sequences that establish the style on a terminal without any attributes.
+func (s *textStyle) open() string {
+	var b strings.Builder
+	if !s.format.IsZero() {
+		b.WriteString(StartFormat)
+		b.WriteString(sgrTransition(NewFormat(), s.format, ProfileTrueColor))
+		b.WriteString(EndFormat)
+	}
+	b.WriteString(s.link)
+	return b.String()
+}
+
+// close returns the escape sequences that end the style, leaving the terminal without attributes.
+func (s *textStyle) close() string {
+	var b strings.Builder
+	if !s.format.IsZero() {
+		b.WriteString(ClearString)
+	}
+	if s.link != "" {
+		b.WriteString(closeHyperlink)
+	}
+	return b.String()
+}
+
+// hyperlinkURI returns the URI of an OSC 8 hyperlink token, which is empty when the token closes a link.
+func hyperlinkURI(tok Token) (string, bool) {
+	if tok.Kind != TokenOSC || !strings.HasPrefix(tok.Data, oscHyperlink+";") {
+		return "", false
+	}
+	_, uri, ok := strings.Cut(tok.Data[len(oscHyperlink)+1:], ";")
+	return uri, ok
+}
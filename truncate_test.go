@@ -0,0 +1,60 @@
+package ansicolor
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		tail  string
+		want  string
+	}{
+		{name: "fits", s: "\033[1mabc\033[0m", width: 3, tail: "…", want: "\033[1mabc\033[0m"},
+		{name: "plain", s: "abcdef", width: 4, tail: "…", want: "abc…"},
+		{name: "no tail", s: "abcdef", width: 4, want: "abcd"},
+		{name: "zero width", s: "abc", width: 0, tail: "…", want: ""},
+		{name: "negative width", s: "abc", width: -1, want: ""},
+		{name: "wide tail", s: "abcdef", width: 2, tail: "...", want: ".."},
+		// A wide character that does not fit is dropped as a whole.
+		{name: "wide fits", s: "日本語", width: 5, tail: "…", want: "日本…"},
+		{name: "wide at cut", s: "日本語", width: 4, tail: "…", want: "日…"},
+		{name: "wide after narrow", s: "a日本", width: 2, want: "a"},
+		{name: "cluster", s: "👨\u200d👩\u200d👧x", width: 2, want: "👨\u200d👩\u200d👧"},
+		{name: "combining mark", s: "e\u0301e\u0301e\u0301", width: 2, want: "e\u0301e\u0301"},
+		// Styles open at the cut are closed before the tail; sequences after the cut are dropped.
+		{name: "open style", s: "\033[1mbold text\033[0m", width: 6, tail: "…", want: "\033[1mbold \033[0m…"},
+		{name: "closed style", s: "\033[31mab\033[0mcdef", width: 4, tail: "…", want: "\033[31mab\033[0mc…"},
+		{name: "style at cut", s: "abc\033[1mdef", width: 4, tail: "…", want: "abc\033[1m\033[0m…"},
+		{
+			name:  "hyperlink",
+			s:     "\033]8;;http://x\033\\link text\033]8;;\033\\",
+			width: 5,
+			tail:  "…",
+			want:  "\033]8;;http://x\033\\link\033]8;;\033\\…",
+		},
+		{
+			name:  "styled hyperlink",
+			s:     "\033[4m\033]8;id=1;http://x\033\\link text\033]8;;\033\\\033[0m",
+			width: 3,
+			tail:  "…",
+			want:  "\033[4m\033]8;id=1;http://x\033\\li\033[0m\033]8;;\033\\…",
+		},
+		{
+			name:  "closed hyperlink",
+			s:     "\033]8;;http://x\033\\ab\033]8;;\033\\ cdef",
+			width: 4,
+			tail:  "…",
+			want:  "\033]8;;http://x\033\\ab\033]8;;\033\\ …",
+		},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.width, tt.tail)
+		if got != tt.want {
+			t.Errorf("%s: Truncate(%q, %d, %q) = %q, want %q", tt.name, tt.s, tt.width, tt.tail, got, tt.want)
+		}
+		if w := Width(got); w > max(tt.width, 0) {
+			t.Errorf("%s: Width(%q) = %d, want at most %d", tt.name, got, w, tt.width)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package ansicolor
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Special characters affecting grapheme clusters and their width.
+const (
+	zeroWidthNonJoiner  = '\u200c'
+	zeroWidthJoiner     = '\u200d'
+	textPresentation    = '\ufe0e'
+	emojiPresentation   = '\ufe0f'
+	regionalIndicatorLo = '\U0001f1e6'
+	regionalIndicatorHi = '\U0001f1ff'
+	softHyphen          = '\u00ad'
+)
+
+// graphemeClass is the grapheme cluster break property of a character, limited to the values this package
+// distinguishes.
+type graphemeClass int
+
+// The grapheme cluster break classes, following Unicode Standard Annex #29.
+const (
+	graphemeOther graphemeClass = iota
+	graphemeCR
+	graphemeLF
+	graphemeControl
+	graphemeExtend
+	graphemeZWJ
+	graphemeRegionalIndicator
+	graphemeSpacingMark
+	graphemeL
+	graphemeV
+	graphemeT
+	graphemeLV
+	graphemeLVT
+)
+
+// Width returns the number of terminal cells needed to display s. Escape sequences and control characters take
+// no space, East Asian wide and fullwidth characters take two cells, combining marks, zero-width joiners and
+// variation selectors take none, and emoji sequences such as flags, skin tones and ZWJ families count as a
+// single two-cell character.
+func Width(s string) int {
+	w := 0
+	for _, tok := range Tokenize(s) {
+		if tok.Kind == TokenText {
+			w += textWidth(tok.Raw)
+		}
+	}
+	return w
+}
+
+// textWidth returns the display width of text that contains no escape sequences.
+func textWidth(s string) int {
+	w := 0
+	for s != "" {
+		cluster, cw := nextGrapheme(s)
+		w += cw
+		s = s[len(cluster):]
+	}
+	return w
+}
+
+// nextGrapheme returns the first grapheme cluster of s and its display width. s must not be empty.
+func nextGrapheme(s string) (string, int) {
+	r, size := utf8.DecodeRuneInString(s)
+	first, prevClass := r, graphemeClassOf(r)
+	pictographic, riCount := isPictographic(r), 0
+	if prevClass == graphemeRegionalIndicator {
+		riCount = 1
+	}
+	emoji, text := false, false
+	end := size
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		class := graphemeClassOf(r)
+		if !graphemeContinues(prevClass, class, pictographic, riCount, r) {
+			break
+		}
+		switch {
+		case r == emojiPresentation:
+			emoji = true
+		case r == textPresentation:
+			text = true
+		case class == graphemeRegionalIndicator:
+			riCount++
+		}
+		prevClass = class
+		end += size
+	}
+	return s[:end], clusterWidth(first, riCount, emoji, text)
+}
+
+// graphemeContinues reports whether a character of class next extends a cluster whose last character has class
+// prev. pictographic tells whether the cluster starts with an extended pictographic character and riCount is the
+// number of regional indicators it holds.
+func graphemeContinues(prev, next graphemeClass, pictographic bool, riCount int, r rune) bool {
+	switch {
+	case prev == graphemeCR:
+		return next == graphemeLF
+	case prev == graphemeLF || prev == graphemeControl:
+		return false
+	case next == graphemeCR || next == graphemeLF || next == graphemeControl:
+		return false
+	case next == graphemeExtend || next == graphemeZWJ || next == graphemeSpacingMark:
+		return true
+	case prev == graphemeL:
+		return next == graphemeL || next == graphemeV || next == graphemeLV || next == graphemeLVT
+	case prev == graphemeV || prev == graphemeLV:
+		return next == graphemeV || next == graphemeT
+	case prev == graphemeT || prev == graphemeLVT:
+		return next == graphemeT
+	case prev == graphemeZWJ:
+		return pictographic && isPictographic(r)
+	case prev == graphemeRegionalIndicator:
+		return next == graphemeRegionalIndicator && riCount%2 == 1
+	}
+	return false
+}
+
+// clusterWidth returns the display width of a grapheme cluster starting with first. riCount is the number of
+// regional indicators in the cluster; emoji and text tell whether it holds an emoji or text presentation selector.
+func clusterWidth(first rune, riCount int, emoji, text bool) int {
+	switch {
+	case riCount == 2:
+		return 2
+	case emoji && isPictographic(first):
+		return 2
+	case text && isPictographic(first):
+		return 1
+	}
+	return runeWidth(first)
+}
+
+// runeWidth returns the number of cells a single character occupies when displayed on its own.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || r >= 0x7f && r < 0xa0:
+		return 0
+	case r < 0x7f:
+		return 1
+	case r == softHyphen:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1160 && r <= 0x11ff || r >= 0xd7b0 && r <= 0xd7ff:
+		// Hangul medial vowels and final consonants combine with the preceding initial consonant.
+		return 0
+	case unicode.Is(wideTable, r):
+		return 2
+	}
+	return 1
+}
+
+// graphemeClassOf returns the grapheme cluster break class of r.
+func graphemeClassOf(r rune) graphemeClass {
+	switch {
+	case r == '\r':
+		return graphemeCR
+	case r == '\n':
+		return graphemeLF
+	case r < 0x20 || r >= 0x7f && r < 0xa0:
+		return graphemeControl
+	case r < 0x300:
+		return graphemeOther
+	case r == zeroWidthJoiner:
+		return graphemeZWJ
+	case r == zeroWidthNonJoiner || r >= 0xfe00 && r <= 0xfe0f || r >= 0xe0100 && r <= 0xe01ef:
+		return graphemeExtend
+	case r >= 0x1f3fb && r <= 0x1f3ff || r >= 0xe0020 && r <= 0xe007f:
+		// Emoji skin tone modifiers and tag characters.
+		return graphemeExtend
+	case r >= regionalIndicatorLo && r <= regionalIndicatorHi:
+		return graphemeRegionalIndicator
+	case r >= 0x1100 && r <= 0x115f || r >= 0xa960 && r <= 0xa97c:
+		return graphemeL
+	case r >= 0x1160 && r <= 0x11a7 || r >= 0xd7b0 && r <= 0xd7c6:
+		return graphemeV
+	case r >= 0x11a8 && r <= 0x11ff || r >= 0xd7cb && r <= 0xd7fb:
+		return graphemeT
+	case r >= 0xac00 && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return graphemeLV
+		}
+		return graphemeLVT
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return graphemeExtend
+	case unicode.Is(unicode.Mc, r):
+		return graphemeSpacingMark
+	case unicode.In(r, unicode.Zl, unicode.Zp, unicode.Cf):
+		return graphemeControl
+	}
+	return graphemeOther
+}
+
+// pictographicTable approximates the Extended_Pictographic property: the characters that may start or join an
+// emoji sequence.
+var pictographicTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a9, Hi: 0x00ae, Stride: 5},
+		{Lo: 0x203c, Hi: 0x203c, Stride: 1},
+		{Lo: 0x2049, Hi: 0x2049, Stride: 1},
+		{Lo: 0x2122, Hi: 0x2122, Stride: 1},
+		{Lo: 0x2139, Hi: 0x2139, Stride: 1},
+		{Lo: 0x2194, Hi: 0x2199, Stride: 1},
+		{Lo: 0x21a9, Hi: 0x21aa, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2328, Hi: 0x2328, Stride: 1},
+		{Lo: 0x2388, Hi: 0x2388, Stride: 1},
+		{Lo: 0x23cf, Hi: 0x23cf, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x23f8, Hi: 0x23fa, Stride: 1},
+		{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+		{Lo: 0x25aa, Hi: 0x25ab, Stride: 1},
+		{Lo: 0x25b6, Hi: 0x25b6, Stride: 1},
+		{Lo: 0x25c0, Hi: 0x25c0, Stride: 1},
+		{Lo: 0x25fb, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+		{Lo: 0x2b05, Hi: 0x2b07, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2b55, Hi: 0x2b55, Stride: 1},
+		{Lo: 0x3030, Hi: 0x3030, Stride: 1},
+		{Lo: 0x303d, Hi: 0x303d, Stride: 1},
+		{Lo: 0x3297, Hi: 0x3299, Stride: 2},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1f0ff, Stride: 1},
+		{Lo: 0x1f10d, Hi: 0x1f10f, Stride: 1},
+		{Lo: 0x1f12f, Hi: 0x1f12f, Stride: 1},
+		{Lo: 0x1f16c, Hi: 0x1f171, Stride: 1},
+		{Lo: 0x1f17e, Hi: 0x1f17f, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f1ad, Hi: 0x1f1e5, Stride: 1},
+		{Lo: 0x1f201, Hi: 0x1f20f, Stride: 1},
+		{Lo: 0x1f21a, Hi: 0x1f21a, Stride: 1},
+		{Lo: 0x1f22f, Hi: 0x1f22f, Stride: 1},
+		{Lo: 0x1f232, Hi: 0x1f23a, Stride: 1},
+		{Lo: 0x1f23c, Hi: 0x1f23f, Stride: 1},
+		{Lo: 0x1f249, Hi: 0x1f3fa, Stride: 1},
+		{Lo: 0x1f400, Hi: 0x1f53d, Stride: 1},
+		{Lo: 0x1f546, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f774, Hi: 0x1f77f, Stride: 1},
+		{Lo: 0x1f7d5, Hi: 0x1f7ff, Stride: 1},
+		{Lo: 0x1f80c, Hi: 0x1f80f, Stride: 1},
+		{Lo: 0x1f848, Hi: 0x1f84f, Stride: 1},
+		{Lo: 0x1f85a, Hi: 0x1f85f, Stride: 1},
+		{Lo: 0x1f888, Hi: 0x1f88f, Stride: 1},
+		{Lo: 0x1f8ae, Hi: 0x1f8ff, Stride: 1},
+		{Lo: 0x1f90c, Hi: 0x1f93a, Stride: 1},
+		{Lo: 0x1f93c, Hi: 0x1f945, Stride: 1},
+		{Lo: 0x1f947, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x1fc00, Hi: 0x1fffd, Stride: 1},
+	},
+	LatinOffset: 1,
+}
+
+// isPictographic reports whether r is an extended pictographic character such as an emoji.
+func isPictographic(r rune) bool {
+	return unicode.Is(pictographicTable, r)
+}
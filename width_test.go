@@ -0,0 +1,72 @@
+package ansicolor
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{s: "", want: 0},
+		{s: "hello", want: 5},
+		{s: "\033[1;31mred\033[0m", want: 3},
+		{s: "a\tb\n", want: 2},
+		{s: "日本語", want: 6},
+		{s: "ｈｉ", want: 4},
+		{s: "한글", want: 4},
+		{s: "é", want: 1},
+		{s: "\u00ad", want: 1},
+		{s: "👍", want: 2},
+		{s: "👍🏽", want: 2},
+		{s: "👨\u200d👩\u200d👧", want: 2},
+		{s: "🇯🇵", want: 2},
+		{s: "☺", want: 1},
+		{s: "☺\ufe0f", want: 2},
+		{s: "⌚\ufe0e", want: 1},
+		// Unassigned code points are narrow, except in the ranges that default to wide.
+		{s: "\u0378", want: 1},
+		{s: "\u0530", want: 1},
+		{s: "\U0001000c", want: 1},
+		{s: "\U0001fbfa", want: 1},
+		{s: "\u9ffe", want: 2},
+		{s: "\ufa6e", want: 2},
+		{s: "\U0002fffd", want: 2},
+		{s: "\U0003fffd", want: 2},
+		{s: "\U0002fffe", want: 1},
+		// Characters added in Unicode 15.0.
+		{s: "\U00031350", want: 2},
+		{s: "\U0001b132", want: 2},
+		{s: "\U0001f6dc", want: 2},
+		{s: "\U0001fae8", want: 2},
+		{s: "\U0001d2c0", want: 1},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.s); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{r: 'a', want: 1},
+		{r: '\x07', want: 0},
+		{r: '\u0085', want: 0},
+		{r: '\u0300', want: 0},
+		{r: '\u200b', want: 0},
+		{r: '\u1160', want: 0},
+		{r: 'ᄀ', want: 2},
+		{r: '　', want: 2},
+		{r: '！', want: 2},
+		{r: '｡', want: 1},
+		{r: '\U0001f600', want: 2},
+	}
+	for _, tt := range tests {
+		if got := runeWidth(tt.r); got != tt.want {
+			t.Errorf("runeWidth(%U) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package ansicolor
+
+import (
+	"strings"
+)
+
+// WordWrapper wraps styled text to a terminal width. Lines are broken at spaces and between wide characters,
+// words longer than a line are broken wherever they reach the width, and explicit newlines start a new paragraph.
+//
+// Every line is self-contained: the style and hyperlink active at a line break are closed at the end of the line
+// and re-opened after the indentation of the next one, so lines can be printed, reordered or cut independently.
+type WordWrapper struct {
+	// Width is the maximum number of terminal cells per line, including the indentation.
+	Width int
+	// Indent is written at the start of the first line of every paragraph.
+	Indent string
+	// HangingIndent is written at the start of every continuation line of a paragraph.
+	HangingIndent string
+}
+
+// WordWrap wraps s to lines of at most width terminal cells. See WordWrapper for the details.
+func WordWrap(s string, width int) string {
+	return WordWrapper{Width: width}.Wrap(s)
+}
+
+// wrapPiece is an indivisible part of the text being wrapped: a grapheme cluster or an escape sequence.
+type wrapPiece struct {
+	raw   string
+	width int
+	tok   *Token // the escape sequence or control character, nil for text
+}
+
+// wrapState holds the output and the current line while wrapping.
+type wrapState struct {
+	opts       WordWrapper
+	b          strings.Builder
+	style      textStyle
+	line       bool // whether the current line has been started
+	lineWidth  int
+	paragraph  bool // whether the current line is the first line of its paragraph
+	firstWord  bool // whether no word has been placed in the paragraph yet
+	word       []wrapPiece
+	wordWidth  int
+	space      []wrapPiece
+	spaceWidth int
+}
+
+// Wrap wraps s according to the WordWrapper settings.
+func (w WordWrapper) Wrap(s string) string {
+	st := &wrapState{opts: w, style: newTextStyle(), paragraph: true, firstWord: true}
+	for _, tok := range Tokenize(s) {
+		switch {
+		case tok.Kind == TokenText:
+			for text := tok.Raw; text != ""; {
+				cluster, cw := nextGrapheme(text)
+				text = text[len(cluster):]
+				st.addCluster(cluster, cw)
+			}
+		case tok.Kind == TokenControl && tok.Final == '\n':
+			st.flushWord()
+			st.newParagraph()
+		case tok.Kind == TokenControl && tok.Final == '\t':
+			st.addCluster(" ", 1)
+		default:
+			tok := tok
+			st.addPiece(wrapPiece{raw: tok.Raw, tok: &tok})
+		}
+	}
+	st.flushWord()
+	st.emitEscapes(st.space)
+	if st.line {
+		st.b.WriteString(st.style.close())
+	}
+	return st.b.String()
+}
+
+// addCluster adds a grapheme cluster to the current word, or ends the word at a space or after a wide character.
+func (st *wrapState) addCluster(cluster string, width int) {
+	if cluster == " " {
+		if len(st.word) > 0 {
+			st.flushWord()
+		}
+		st.space = append(st.space, wrapPiece{raw: cluster, width: width})
+		st.spaceWidth += width
+		return
+	}
+	st.addPiece(wrapPiece{raw: cluster, width: width})
+	if width > 1 {
+		// Wide characters, as used by CJK scripts, may be broken between without a space.
+		st.flushWord()
+	}
+}
+
+// addPiece adds a piece to the current word.
+func (st *wrapState) addPiece(p wrapPiece) {
+	st.word = append(st.word, p)
+	st.wordWidth += p.width
+}
+
+// available returns the number of cells available for text on the current line.
+func (st *wrapState) available() int {
+	indent := st.opts.HangingIndent
+	if st.paragraph {
+		indent = st.opts.Indent
+	}
+	if n := st.opts.Width - Width(indent); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// flushWord places the current word and the spaces before it, breaking the line if the word does not fit.
+func (st *wrapState) flushWord() {
+	if len(st.word) == 0 {
+		return
+	}
+	space, word := st.space, st.word
+	spaceWidth, wordWidth := st.spaceWidth, st.wordWidth
+	st.space, st.word = nil, nil
+	st.spaceWidth, st.wordWidth = 0, 0
+
+	switch {
+	case st.lineWidth == 0 && st.firstWord:
+		// Keep the leading spaces of a paragraph, unless they alone fill the line.
+		if spaceWidth+wordWidth <= st.available() {
+			st.emit(space)
+		} else {
+			st.emitEscapes(space)
+		}
+	case st.lineWidth == 0:
+		st.emitEscapes(space)
+	case st.lineWidth+spaceWidth+wordWidth <= st.available():
+		st.emit(space)
+		st.emit(word)
+		st.firstWord = false
+		return
+	default:
+		st.emitEscapes(space)
+		st.endLine()
+	}
+	st.firstWord = false
+
+	if st.lineWidth+wordWidth <= st.available() {
+		st.emit(word)
+		return
+	}
+	// The word is longer than a line: break it wherever it reaches the width.
+	for _, p := range word {
+		if p.tok == nil && st.lineWidth > 0 && st.lineWidth+p.width > st.available() {
+			st.endLine()
+		}
+		st.emit([]wrapPiece{p})
+	}
+}
+
+// emit writes pieces to the current line, starting the line if needed. Style changes between lines are only
+// recorded, as they are re-opened at the start of the next line.
+func (st *wrapState) emit(pieces []wrapPiece) {
+	for _, p := range pieces {
+		if p.tok != nil {
+			st.style.update(*p.tok)
+			if _, link := hyperlinkURI(*p.tok); !st.line && (p.tok.IsSGR() || link) {
+				continue
+			}
+		}
+		if !st.line && p.tok == nil {
+			st.startLine()
+		}
+		st.b.WriteString(p.raw)
+		st.lineWidth += p.width
+	}
+}
+
+// emitEscapes writes only the escape sequences among pieces, so that dropped spaces keep their effect on the
+// style.
+func (st *wrapState) emitEscapes(pieces []wrapPiece) {
+	for _, p := range pieces {
+		if p.tok != nil {
+			st.emit([]wrapPiece{p})
+		}
+	}
+}
+
+// startLine writes the indentation and re-opens the active style.
+func (st *wrapState) startLine() {
+	if st.paragraph {
+		st.b.WriteString(st.opts.Indent)
+	} else {
+		st.b.WriteString(st.opts.HangingIndent)
+	}
+	st.b.WriteString(st.style.open())
+	st.line = true
+	st.lineWidth = 0
+}
+
+// endLine closes the active style and ends the current line; the next line is a continuation line.
+func (st *wrapState) endLine() {
+	if st.line {
+		st.b.WriteString(st.style.close())
+	}
+	st.b.WriteByte('\n')
+	st.line = false
+	st.lineWidth = 0
+	st.paragraph = false
+}
+
+// newParagraph ends the current line at an explicit newline. Pending spaces are dropped.
+func (st *wrapState) newParagraph() {
+	st.emitEscapes(st.space)
+	st.space, st.spaceWidth = nil, 0
+	st.endLine()
+	st.paragraph = true
+	st.firstWord = true
+}
@@ -0,0 +1,97 @@
+package ansicolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWordWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  string
+	}{
+		{name: "fits", s: "abc", width: 10, want: "abc"},
+		{name: "spaces", s: "the quick brown fox", width: 10, want: "the quick\nbrown fox"},
+		{name: "spaces at break", s: "ab   cd", width: 3, want: "ab\ncd"},
+		{name: "leading spaces", s: "  ab cd", width: 5, want: "  ab\ncd"},
+		{name: "paragraphs", s: "ab cd\nef", width: 2, want: "ab\ncd\nef"},
+		{name: "wide", s: "日本語です", width: 4, want: "日本\n語で\nす"},
+		// Words longer than a line are broken wherever they reach the width.
+		{name: "long word", s: "abcdefghij", width: 4, want: "abcd\nefgh\nij"},
+		{name: "long word after short", s: "a bcdefgh", width: 4, want: "a\nbcde\nfgh"},
+		{name: "long word with cluster", s: "abe\u0301cd", width: 3, want: "abe\u0301\ncd"},
+		// Styles and hyperlinks are closed at every line break and re-opened on the next line.
+		{name: "style", s: "\033[1mab cd\033[0m", width: 2, want: "\033[1mab\033[0m\n\033[1mcd\033[0m"},
+		{name: "style in long word", s: "\033[4mabcdef", width: 3, want: "\033[4mabc\033[0m\n\033[4mdef\033[0m"},
+		{
+			name:  "style change at break",
+			s:     "\033[31mab \033[1mcd\033[0m ef",
+			width: 2,
+			want:  "\033[31mab\033[0m\n\033[1;31mcd\033[0m\nef",
+		},
+		{
+			name:  "hyperlink",
+			s:     "\033]8;;http://x\033\\ab cd\033]8;;\033\\",
+			width: 2,
+			want:  "\033]8;;http://x\033\\ab\033]8;;\033\\\n\033]8;;http://x\033\\cd\033]8;;\033\\",
+		},
+	}
+	for _, tt := range tests {
+		got := WordWrap(tt.s, tt.width)
+		if got != tt.want {
+			t.Errorf("%s: WordWrap(%q, %d) = %q, want %q", tt.name, tt.s, tt.width, got, tt.want)
+		}
+		for _, line := range strings.Split(got, "\n") {
+			if Width(line) > tt.width {
+				t.Errorf("%s: line %q is wider than %d cells", tt.name, line, tt.width)
+			}
+		}
+	}
+}
+
+func TestWordWrapperIndent(t *testing.T) {
+	tests := []struct {
+		name string
+		w    WordWrapper
+		s    string
+		want string
+	}{
+		{
+			name: "hanging indent",
+			w:    WordWrapper{Width: 10, Indent: "- ", HangingIndent: "  "},
+			s:    "one two three four",
+			want: "- one two\n  three\n  four",
+		},
+		{
+			name: "paragraphs",
+			w:    WordWrapper{Width: 5, Indent: "* ", HangingIndent: "  "},
+			s:    "ab cd\nef",
+			want: "* ab\n  cd\n* ef",
+		},
+		{
+			name: "styled",
+			w:    WordWrapper{Width: 8, HangingIndent: "  "},
+			s:    "\033[31mred text here\033[0m",
+			want: "\033[31mred text\033[0m\n  \033[31mhere\033[0m",
+		},
+		{
+			name: "long word",
+			w:    WordWrapper{Width: 5, Indent: "> ", HangingIndent: ">   "},
+			s:    "abcdefg",
+			want: "> abc\n>   d\n>   e\n>   f\n>   g",
+		},
+		{
+			name: "indent wider than width",
+			w:    WordWrapper{Width: 2, HangingIndent: "    "},
+			s:    "ab cd",
+			want: "ab\n    c\n    d",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.w.Wrap(tt.s); got != tt.want {
+			t.Errorf("%s: Wrap(%q) = %q, want %q", tt.name, tt.s, got, tt.want)
+		}
+	}
+}